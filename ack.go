@@ -0,0 +1,163 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"./model"
+	"./util"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+var ackGroupId string
+var ackAlertId string
+var ackHostname string
+var ackMetricName string
+var ackDuration time.Duration
+var ackComment string
+var ackServer string
+var ackTimeout int
+
+// runAck acknowledges an alert given by its ID, or the open alerts of a host
+// and optionally a metric, so it can be called from a Nagios event handler
+// when a problem is acknowledged. Only open alerts can be acknowledged, and
+// alerts that already are keep their acknowledgement, comment and user, so
+// running it twice is safe.
+func runAck(args []string) {
+	flags := setupAckFlags(args)
+	if ackGroupId == "" || (ackAlertId == "" && ackHostname == "") || ackDuration <= 0 {
+		flags.Usage()
+		os.Exit(2)
+		return
+	}
+
+	api, err := newAPI(ackServer, ackTimeout)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	alerts, err := getAlertsToAck(api)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	if len(alerts) == 0 {
+		fmt.Fprintf(os.Stdout, "No open alerts to acknowledge\n")
+		return
+	}
+
+	now := time.Now()
+	until := now.Add(ackDuration)
+	for _, alert := range alerts {
+		if alert.Status != model.AlertOpen {
+			fmt.Fprintf(os.Stdout, "Alert %v is %v, only open alerts can be acknowledged: %v\n", alert.Id, alert.Status, alert)
+			continue
+		}
+
+		if alert.IsAcknowledged(now) {
+			fmt.Fprintf(os.Stdout, "Alert %v is already acknowledged until %v: %v\n", alert.Id, alert.AcknowledgedUntil.Format(time.RFC3339), alert)
+			continue
+		}
+
+		if _, err := api.AcknowledgeAlert(ackGroupId, alert.Id, until, ackComment); err != nil {
+			exitWithError(errors.New(fmt.Sprintf("Failed to acknowledge alert %v. Error: %v", alert.Id, err)))
+		}
+
+		fmt.Fprintf(os.Stdout, "Acknowledged alert %v until %v: %v\n", alert.Id, until.Format(time.RFC3339), alert)
+	}
+}
+
+// getAlertsToAck returns the alert with the given ID, or the open alerts of
+// the host for the metric.
+func getAlertsToAck(api *util.MMSAPI) ([]model.Alert, error) {
+	if ackAlertId != "" {
+		alert, err := api.GetAlert(ackGroupId, ackAlertId)
+		if err != nil {
+			return nil, err
+		}
+
+		return []model.Alert{*alert}, nil
+	}
+
+	alerts, err := api.GetAlerts(ackGroupId, model.AlertOpen)
+	if err != nil {
+		return nil, err
+	}
+
+	var matching []model.Alert
+	for _, alert := range alerts {
+		if alert.HostnameAndPort == ackHostname && (ackMetricName == "" || alert.MetricName == ackMetricName) {
+			matching = append(matching, alert)
+		}
+	}
+
+	return matching, nil
+}
+
+func setupAckFlags(args []string) *flag.FlagSet {
+	const (
+		groupIdDefault  = ""
+		groupIdUsage    = "The MMS/Ops Manager group ID of the alert"
+		alertIdDefault  = ""
+		alertIdUsage    = "ID of the alert to acknowledge"
+		hostnameDefault = ""
+		hostnameUsage   = "hostname:port of the mongod/s whose open alerts are acknowledged"
+		metricDefault   = ""
+		metricUsage     = "only acknowledge the host's alerts for the metric"
+		durationDefault = 24 * time.Hour
+		durationUsage   = "how long the alert is acknowledged for, such as 30m or 4h"
+		commentDefault  = ""
+		commentUsage    = "comment shown with the acknowledgement"
+		serverDefault   = "https://mms.mongodb.com"
+		serverUsage     = "hostname and port of the MMS/Ops Manager service"
+		timeoutDefault  = 10
+		timeoutUsage    = "connection timeout connecting MMS/Ops Manager service"
+	)
+
+	flags := flag.NewFlagSet("ack", flag.ExitOnError)
+
+	flags.StringVar(&ackGroupId, "groupid", groupIdDefault, groupIdUsage)
+	flags.StringVar(&ackGroupId, "g", groupIdDefault, groupIdUsage)
+
+	flags.StringVar(&ackAlertId, "alert", alertIdDefault, alertIdUsage)
+	flags.StringVar(&ackAlertId, "A", alertIdDefault, alertIdUsage)
+
+	flags.StringVar(&ackHostname, "hostname", hostnameDefault, hostnameUsage)
+	flags.StringVar(&ackHostname, "H", hostnameDefault, hostnameUsage)
+
+	flags.StringVar(&ackMetricName, "metric", metricDefault, metricUsage)
+	flags.StringVar(&ackMetricName, "m", metricDefault, metricUsage)
+
+	flags.DurationVar(&ackDuration, "duration", durationDefault, durationUsage)
+	flags.DurationVar(&ackDuration, "D", durationDefault, durationUsage)
+
+	flags.StringVar(&ackComment, "comment", commentDefault, commentUsage)
+	flags.StringVar(&ackComment, "C", commentDefault, commentUsage)
+
+	flags.StringVar(&ackServer, "server", serverDefault, serverUsage)
+	flags.StringVar(&ackServer, "s", serverDefault, serverUsage)
+
+	flags.IntVar(&ackTimeout, "timeout", timeoutDefault, timeoutUsage)
+	flags.IntVar(&ackTimeout, "t", timeoutDefault, timeoutUsage)
+
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stdout, "Usage: check_mongodb_mms ack -g groupid -A alert [-D duration] [-C comment] [-s server] [-t timeout]\n")
+		fmt.Fprintf(os.Stdout, "       check_mongodb_mms ack -g groupid -H hostname [-m metric] [-D duration] [-C comment] [-s server] [-t timeout]\n")
+		fmt.Fprintf(os.Stdout, "     -g, --groupid  %v\n", groupIdUsage)
+		fmt.Fprintf(os.Stdout, "     -A, --alert %v\n", alertIdUsage)
+		fmt.Fprintf(os.Stdout, "     -H, --hostname %v\n", hostnameUsage)
+		fmt.Fprintf(os.Stdout, "     -m, --metric %v\n", metricUsage)
+		fmt.Fprintf(os.Stdout, "     -D, --duration (default: %v) %v\n", durationDefault, durationUsage)
+		fmt.Fprintf(os.Stdout, "     -C, --comment %v\n", commentUsage)
+		fmt.Fprintf(os.Stdout, "     -s, --server (default: %v) %v\n", serverDefault, serverUsage)
+		fmt.Fprintf(os.Stdout, "     -t, --timeout (default: %v) %v\n", timeoutDefault, timeoutUsage)
+	}
+	flags.Parse(args)
+
+	return flags
+}
@@ -0,0 +1,133 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"./model"
+	"./util"
+	"flag"
+	"fmt"
+	"github.com/fractalcat/nagiosplugin"
+	"math"
+	"os"
+	"strings"
+	"time"
+)
+
+var agentTypes = map[string]bool{
+	model.AgentMonitoring: true,
+	model.AgentBackup:     true,
+	model.AgentAutomation: true,
+}
+
+var agentType string
+var requireAgent bool
+
+// doAgentsCheck lists the agents of the type with their state, version and
+// last ping. Monitoring and backup agents need an active agent, whose last
+// ping age in seconds is checked against the warning and critical ranges.
+// Every host has its own automation agent, so for those the oldest last ping
+// is checked.
+func doAgentsCheck(check *nagiosplugin.Check, api *util.MMSAPI, host *model.Host) {
+	typeName := strings.ToUpper(agentType)
+	if !agentTypes[typeName] {
+		check.AddResultf(nagiosplugin.UNKNOWN, "Unknown agent type %v", agentType)
+		return
+	}
+
+	agents, err := api.GetAgents(groupId, typeName)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	kind := strings.ToLower(typeName)
+	if len(agents) == 0 {
+		check.AddResultf(nagiosplugin.CRITICAL, "No %v agents found", kind)
+		return
+	}
+
+	var lines []string
+	var active []model.Agent
+	oldest, newestActive := 0.0, math.Inf(1)
+	for _, agent := range agents {
+		age := time.Since(agent.LastPing).Seconds()
+		oldest = math.Max(oldest, age)
+		if agent.StateName == model.AgentActive {
+			active = append(active, agent)
+			newestActive = math.Min(newestActive, age)
+		}
+
+		version := agent.Version
+		if version == "" {
+			version = "unknown"
+		}
+		lines = append(lines, fmt.Sprintf("%v %v agent on %v, version %v, last ping %v seconds ago",
+			agent.StateName, kind, agent.Hostname, version, int(age)))
+	}
+
+	check.AddPerfDatum("agents", "", float64(len(agents)))
+	check.AddPerfDatum("active_agents", "", float64(len(active)))
+
+	var age float64
+	var message string
+	if typeName == model.AgentAutomation {
+		age = oldest
+		message = fmt.Sprintf("%v %v agents, the oldest last ping was %v seconds ago", len(agents), kind, int(age))
+	} else {
+		if len(active) == 0 {
+			check.AddResultf(nagiosplugin.CRITICAL, "No active %v agent among %v agents\n%v", kind, len(agents), strings.Join(lines, "\n"))
+			return
+		}
+
+		age = newestActive
+		message = fmt.Sprintf("%v active %v agents, the last ping was %v seconds ago", len(active), kind, int(age))
+	}
+	check.AddPerfDatum("last_ping_age", "s", age)
+
+	status, err := checkThresholds(age)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	check.AddResultf(status, "%v\n%v", message, strings.Join(lines, "\n"))
+}
+
+// monitoringAgentDown returns true if no monitoring agent of the group is
+// active and has pinged within the maximum age, in which case the data the
+// other checks look at is stale.
+func monitoringAgentDown(api *util.MMSAPI) (bool, error) {
+	agents, err := api.GetAgents(groupId, model.AgentMonitoring)
+	if err != nil {
+		return false, err
+	}
+
+	for _, agent := range agents {
+		if agent.StateName == model.AgentActive && time.Since(agent.LastPing) <= time.Duration(maxAge)*time.Second {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+const (
+	agentTypeDefault    = "monitoring"
+	agentTypeUsage      = "the agents to check: monitoring, backup or automation"
+	requireAgentDefault = false
+	requireAgentUsage   = "report UNKNOWN \"monitoring agent down\" instead of running the check when no monitoring agent has pinged within -a seconds"
+)
+
+func setupAgentsFlags() {
+	flag.StringVar(&agentType, "agent-type", agentTypeDefault, agentTypeUsage)
+	flag.BoolVar(&requireAgent, "require-agent", requireAgentDefault, requireAgentUsage)
+}
+
+func agentsUsage() {
+	fmt.Fprintf(os.Stdout, "\n     agents mode: -w and -c are ranges of the seconds since the last ping of the active agent, e.g. -w 120 -c 300\n")
+	fmt.Fprintf(os.Stdout, "     --agent-type (default: %v) %v\n", agentTypeDefault, agentTypeUsage)
+	fmt.Fprintf(os.Stdout, "\n     --require-agent %v\n", requireAgentUsage)
+}
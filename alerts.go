@@ -0,0 +1,154 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"./model"
+	"./util"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/fractalcat/nagiosplugin"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// AlertAcknowledged is the severity key of alerts acknowledged in
+	// MMS/Ops Manager.
+	AlertAcknowledged = "ACKNOWLEDGED"
+
+	// AlertAny is the severity key of alerts no other key matches.
+	AlertAny = "*"
+)
+
+var states = map[string]nagiosplugin.Status{
+	"ok":       nagiosplugin.OK,
+	"warning":  nagiosplugin.WARNING,
+	"critical": nagiosplugin.CRITICAL,
+	"unknown":  nagiosplugin.UNKNOWN,
+}
+
+var alertEventType string
+var alertConfigId string
+var alertSeverity string
+
+// doAlertsCheck reports the open alerts of the group that match the filters.
+// The state is the worst of the states the alerts map to, and every alert is
+// listed in the long output.
+func doAlertsCheck(check *nagiosplugin.Check, api *util.MMSAPI, host *model.Host) {
+	severity, err := parseSeverity(alertSeverity)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	alerts, err := api.GetAlerts(groupId, model.AlertOpen)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	now := time.Now()
+	status := nagiosplugin.OK
+	var lines []string
+	for _, alert := range alerts {
+		if !alertMatches(alert) {
+			continue
+		}
+
+		alertStatus := alertState(alert, severity, now)
+		if statusSeverity(alertStatus) > statusSeverity(status) {
+			status = alertStatus
+		}
+
+		line := fmt.Sprintf("%v: %v", alertStatus, alert)
+		if alert.IsAcknowledged(now) {
+			line += fmt.Sprintf(" (acknowledged by %v until %v: %v)", alert.AcknowledgingUsername,
+				alert.AcknowledgedUntil.Format(time.RFC3339), alert.AcknowledgementComment)
+		}
+		lines = append(lines, line)
+	}
+
+	check.AddPerfDatum("open_alerts", "", float64(len(lines)))
+	if len(lines) == 0 {
+		check.AddResult(nagiosplugin.OK, "No open alerts")
+		return
+	}
+
+	check.AddResultf(status, "%v open alerts\n%v", len(lines), strings.Join(lines, "\n"))
+}
+
+// alertMatches returns true if the alert passes the host, replica set, event
+// type and alert configuration filters.
+func alertMatches(alert model.Alert) bool {
+	return (hostname == "" || alert.HostnameAndPort == hostname) &&
+		(replicaSet == "" || alert.ReplicaSetName == replicaSet) &&
+		(alertEventType == "" || alert.EventTypeName == alertEventType) &&
+		(alertConfigId == "" || alert.AlertConfigId == alertConfigId)
+}
+
+// alertState maps an alert to a state. Acknowledged alerts use the
+// ACKNOWLEDGED key if it is given. Otherwise the metric name, event type
+// name and alert type name are tried in turn, then the * key.
+func alertState(alert model.Alert, severity map[string]nagiosplugin.Status, now time.Time) nagiosplugin.Status {
+	keys := []string{alert.MetricName, alert.EventTypeName, alert.TypeName, AlertAny}
+	if alert.IsAcknowledged(now) {
+		keys = append([]string{AlertAcknowledged}, keys...)
+	}
+
+	for _, key := range keys {
+		if status, ok := severity[key]; key != "" && ok {
+			return status
+		}
+	}
+
+	return nagiosplugin.CRITICAL
+}
+
+// parseSeverity parses a comma separated list of key=state pairs, such as
+// "*=critical,OUTSIDE_METRIC_THRESHOLD=warning".
+func parseSeverity(text string) (map[string]nagiosplugin.Status, error) {
+	severity := make(map[string]nagiosplugin.Status)
+	for _, pair := range strings.Split(text, ",") {
+		if pair == "" {
+			continue
+		}
+
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, errors.New(fmt.Sprintf("Error parsing severity %q, expected key=state", pair))
+		}
+
+		status, ok := states[strings.ToLower(parts[1])]
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("Error parsing severity %q, unknown state %v", pair, parts[1]))
+		}
+		severity[parts[0]] = status
+	}
+
+	return severity, nil
+}
+
+const (
+	alertEventTypeUsage  = "only report alerts of the event type, such as OUTSIDE_METRIC_THRESHOLD"
+	alertConfigIdUsage   = "only report alerts raised by the alert configuration with the ID"
+	alertSeverityDefault = AlertAny + "=critical"
+	alertSeverityUsage   = "comma separated key=state pairs mapping alerts to ok, warning, critical or unknown by metric name, event type name, alert type name, " + AlertAcknowledged + " or " + AlertAny
+)
+
+func setupAlertsFlags() {
+	flag.StringVar(&alertEventType, "event-type", "", alertEventTypeUsage)
+	flag.StringVar(&alertConfigId, "alert-config", "", alertConfigIdUsage)
+	flag.StringVar(&alertSeverity, "severity", alertSeverityDefault, alertSeverityUsage)
+}
+
+func alertsUsage() {
+	fmt.Fprintf(os.Stdout, "\n     alerts mode: reports the open alerts of the group, -H and --replica-set only report alerts of the host or replica set\n")
+	fmt.Fprintf(os.Stdout, "     --event-type %v\n", alertEventTypeUsage)
+	fmt.Fprintf(os.Stdout, "     --alert-config %v\n", alertConfigIdUsage)
+	fmt.Fprintf(os.Stdout, "     --severity (default: %v) %v\n", alertSeverityDefault, alertSeverityUsage)
+}
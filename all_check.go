@@ -0,0 +1,147 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"./model"
+	"./util"
+	"errors"
+	"fmt"
+	"github.com/fractalcat/nagiosplugin"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+const (
+	// AllSeries as the database or partition name checks all of them.
+	AllSeries = "*"
+
+	// MaxConcurrentRequests limits the number of metrics fetched at once.
+	MaxConcurrentRequests = 8
+)
+
+// doAllMetricCheck checks the metric for every database or partition of the
+// host that matches the filter. Every series is reported as perfdata, but
+// only the worst one is reported as the result.
+func doAllMetricCheck(check *nagiosplugin.Check, api *util.MMSAPI, host *model.Host) {
+	kind := "databases"
+	if partition == AllSeries {
+		kind = "partitions"
+	}
+
+	names, err := getSeriesNames(api, host)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	if len(names) == 0 {
+		check.AddResultf(nagiosplugin.UNKNOWN, "No %v found for %v", kind, metricName)
+		return
+	}
+
+	results := make([]metricResult, len(names))
+	semaphore := make(chan bool, MaxConcurrentRequests)
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			semaphore <- true
+			defer func() { <-semaphore }()
+
+			var metric *model.Metric
+			var err error
+			if partition == AllSeries {
+				metric, err = getMetric(api, groupId, host.Id, metricName, "", name)
+			} else {
+				metric, err = getMetric(api, groupId, host.Id, metricName, name, "")
+			}
+
+			if err != nil {
+				results[i] = metricResult{status: nagiosplugin.UNKNOWN, message: err.Error()}
+			} else {
+				results[i] = checkMetric(metric)
+			}
+			results[i].name = name
+		}(i, name)
+	}
+	wg.Wait()
+
+	counts := make(map[nagiosplugin.Status]int)
+	worst := 0
+	for i, result := range results {
+		if result.hasValue {
+			check.AddPerfDatum(fmt.Sprintf("%v_%v", metricName, result.name), "", result.value)
+		}
+
+		counts[result.status]++
+		if statusSeverity(result.status) > statusSeverity(results[worst].status) {
+			worst = i
+		}
+	}
+
+	check.AddResult(results[worst].status, withProfile(fmt.Sprintf("%v: %v (%v %v: %v critical, %v warning, %v unknown)",
+		results[worst].name, results[worst].message, len(results), kind,
+		counts[nagiosplugin.CRITICAL], counts[nagiosplugin.WARNING], counts[nagiosplugin.UNKNOWN])))
+}
+
+// getSeriesNames returns the sorted database or partition names of the host
+// that match the filter.
+func getSeriesNames(api *util.MMSAPI, host *model.Host) ([]string, error) {
+	var re *regexp.Regexp
+	if filter != "" {
+		var err error
+		if re, err = regexp.Compile(filter); err != nil {
+			return nil, errors.New(fmt.Sprintf("Error parsing filter. Error: %v", err))
+		}
+	}
+
+	var names []string
+	if partition == AllSeries {
+		disks, err := api.GetHostDisks(groupId, host.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, disk := range disks {
+			names = append(names, disk.PartitionName)
+		}
+	} else {
+		databases, err := api.GetHostDatabases(groupId, host.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, database := range databases {
+			names = append(names, database.DatabaseName)
+		}
+	}
+
+	ret := make([]string, 0, len(names))
+	for _, name := range names {
+		if re == nil || re.MatchString(name) {
+			ret = append(ret, name)
+		}
+	}
+
+	sort.Strings(ret)
+	return ret, nil
+}
+
+// statusSeverity orders states from best to worst.
+func statusSeverity(status nagiosplugin.Status) int {
+	switch status {
+	case nagiosplugin.OK:
+		return 0
+	case nagiosplugin.UNKNOWN:
+		return 1
+	case nagiosplugin.WARNING:
+		return 2
+	}
+
+	return 3
+}
@@ -0,0 +1,93 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"./model"
+	"./util"
+	"flag"
+	"fmt"
+	"github.com/fractalcat/nagiosplugin"
+	"math"
+	"os"
+)
+
+const (
+	AnomalyGranularity = "HOUR"
+)
+
+var anomalyHistory int
+var anomalyMethod string
+var anomalySeasonal bool
+
+// doAnomalyCheck scores the last data point of the metric against its
+// history and checks the score against the warning and critical ranges.
+func doAnomalyCheck(check *nagiosplugin.Check, api *util.MMSAPI, host *model.Host) {
+	if metricName == "" {
+		check.AddResultf(nagiosplugin.UNKNOWN, "The anomaly mode requires -m metric")
+		return
+	}
+
+	if err := validateMetric(api, groupId, host.Id, metricName, dbName, partition); err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	query := util.MetricQuery{Granularity: granularityOr(AnomalyGranularity), Period: util.PeriodOfDays(anomalyHistory)}
+	metric, err := getMetricHistory(api, groupId, host.Id, metricName, dbName, partition, query)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	anomaly, err := util.DetectAnomaly(metric.DataPoints, anomalyMethod, anomalySeasonal)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	check.AddPerfDatum(metricName, "", anomaly.Value)
+	check.AddPerfDatum("expected", "", anomaly.Center)
+	if !math.IsInf(anomaly.Score, 0) {
+		check.AddPerfDatum("score", "", anomaly.Score)
+	}
+
+	status, err := checkThresholds(anomaly.Score)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	history := "all hours"
+	if anomalySeasonal {
+		history = "the same hour of the week"
+	}
+
+	check.AddResultf(status, "%v is %v, %+.1f %v from the expected %v (%v data points of %v over %v days)",
+		metricName, anomaly.Value, anomaly.Score, anomalyMethod, anomaly.Center, anomaly.Samples, history, anomalyHistory)
+}
+
+const (
+	anomalyHistoryDefault  = 28
+	anomalyHistoryUsage    = "days of history the last data point is compared against"
+	anomalyMethodDefault   = util.AnomalyZScore
+	anomalyMethodUsage     = "how the score is calculated: zscore (mean and standard deviation) or mad (median and median absolute deviation)"
+	anomalySeasonalDefault = false
+	anomalySeasonalUsage   = "only compare against data points from the same hour of the week"
+)
+
+func setupAnomalyFlags() {
+	flag.IntVar(&anomalyHistory, "history", anomalyHistoryDefault, anomalyHistoryUsage)
+	flag.StringVar(&anomalyMethod, "method", anomalyMethodDefault, anomalyMethodUsage)
+	flag.BoolVar(&anomalySeasonal, "seasonal", anomalySeasonalDefault, anomalySeasonalUsage)
+}
+
+func anomalyUsage() {
+	fmt.Fprintf(os.Stdout, "\n     anomaly mode: -w and -c are ranges of the signed score of the last data point, e.g. -w -3:3 -c -5:5\n")
+	fmt.Fprintf(os.Stdout, "     data points have a granularity of %v unless -G is given\n", AnomalyGranularity)
+	fmt.Fprintf(os.Stdout, "     --history (default: %v) %v\n", anomalyHistoryDefault, anomalyHistoryUsage)
+	fmt.Fprintf(os.Stdout, "     --method (default: %v) %v\n", anomalyMethodDefault, anomalyMethodUsage)
+	fmt.Fprintf(os.Stdout, "     --seasonal %v\n", anomalySeasonalUsage)
+}
@@ -0,0 +1,139 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"./model"
+	"./util"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/fractalcat/nagiosplugin"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+var policyFile string
+
+// policyRule is an alert the group must have. The metric is only compared for
+// metric threshold alerts, and the notification only if a type is given.
+type policyRule struct {
+	Name               string `json:"name"`
+	EventType          string `json:"eventType"`
+	Metric             string `json:"metric,omitempty"`
+	NotificationType   string `json:"notificationType,omitempty"`
+	NotificationTarget string `json:"notificationTarget,omitempty"`
+}
+
+// alertPolicy is the file given with --policy.
+type alertPolicy struct {
+	Rules []policyRule `json:"rules"`
+}
+
+// doAuditCheck checks that the alert configurations of the group meet every
+// rule of the policy, so alerts removed or disabled in the UI don't go
+// unnoticed. Rules that aren't met are a WARNING and listed in the long
+// output.
+func doAuditCheck(check *nagiosplugin.Check, api *util.MMSAPI, host *model.Host) {
+	if policyFile == "" {
+		check.AddResultf(nagiosplugin.UNKNOWN, "The audit mode requires --policy file")
+		return
+	}
+
+	policy, err := loadPolicy(policyFile)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	configs, err := api.GetAlertConfigs(groupId)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	var problems []string
+	for _, rule := range policy.Rules {
+		if problem := auditRule(rule, configs); problem != "" {
+			problems = append(problems, fmt.Sprintf("%v: %v", rule.Name, problem))
+		}
+	}
+
+	check.AddPerfDatum("rules", "", float64(len(policy.Rules)))
+	check.AddPerfDatum("unmet_rules", "", float64(len(problems)))
+	if len(problems) == 0 {
+		check.AddResultf(nagiosplugin.OK, "All %v alert policy rules are met", len(policy.Rules))
+		return
+	}
+
+	check.AddResultf(nagiosplugin.WARNING, "%v of %v alert policy rules are not met\n%v",
+		len(problems), len(policy.Rules), strings.Join(problems, "\n"))
+}
+
+// auditRule returns what is wrong with the alert configurations for the rule,
+// or "" if an enabled alert meets it.
+func auditRule(rule policyRule, configs []model.AlertConfig) string {
+	found, enabled := false, false
+	for _, config := range configs {
+		if config.EventTypeName != rule.EventType || (rule.Metric != "" && config.MetricName() != rule.Metric) {
+			continue
+		}
+
+		found = true
+		if !config.Enabled {
+			continue
+		}
+
+		enabled = true
+		if rule.NotificationType == "" || config.Notifies(rule.NotificationType, rule.NotificationTarget) {
+			return ""
+		}
+	}
+
+	subject := rule.EventType
+	if rule.Metric != "" {
+		subject += " " + rule.Metric
+	}
+
+	switch {
+	case !found:
+		return fmt.Sprintf("no %v alert", subject)
+	case !enabled:
+		return fmt.Sprintf("the %v alert is disabled", subject)
+	case rule.NotificationTarget != "":
+		return fmt.Sprintf("no %v alert notifies %v %v", subject, rule.NotificationType, rule.NotificationTarget)
+	}
+
+	return fmt.Sprintf("no %v alert notifies %v", subject, rule.NotificationType)
+}
+
+func loadPolicy(policyFile string) (*alertPolicy, error) {
+	buffer, err := ioutil.ReadFile(policyFile)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Failed to read policy %v. Error: %v", policyFile, err))
+	}
+
+	policy := &alertPolicy{}
+	if err := json.Unmarshal(buffer, policy); err != nil {
+		return nil, errors.New(fmt.Sprintf("Failed to parse policy %v. Error: %v", policyFile, err))
+	}
+
+	return policy, nil
+}
+
+const (
+	policyUsage = "JSON file of the alerts the group must have"
+)
+
+func setupAuditFlags() {
+	flag.StringVar(&policyFile, "policy", "", policyUsage)
+}
+
+func auditUsage() {
+	fmt.Fprintf(os.Stdout, "\n     audit mode: checks the alert configurations of the group against a policy\n")
+	fmt.Fprintf(os.Stdout, "     --policy %v\n", policyUsage)
+}
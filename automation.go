@@ -0,0 +1,99 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"./model"
+	"./util"
+	"fmt"
+	"github.com/fractalcat/nagiosplugin"
+	"os"
+	"strings"
+	"time"
+)
+
+// automationState remembers since when each process has been behind the
+// goal version, as the automation status only says whether it is.
+type automationState struct {
+	BehindSince map[string]time.Time `json:"behindSince"`
+}
+
+// doAutomationCheck compares the goal version of the automation
+// configuration with the last version each process achieved, and checks the
+// minutes the process furthest behind has been behind against the warning
+// and critical ranges. A process is counted from the first run that found it
+// behind, so if the state is lost the count starts again.
+func doAutomationCheck(check *nagiosplugin.Check, api *util.MMSAPI, host *model.Host) {
+	automation, err := api.GetAutomationStatus(groupId)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	dir, err := stateDirPath()
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	store := util.NewStateStore(dir)
+	key := strings.Join([]string{ModeAutomation, groupId}, "/")
+	var previous automationState
+	store.Load(key, &previous)
+
+	now := time.Now()
+	next := automationState{BehindSince: make(map[string]time.Time)}
+	var longest time.Duration
+	var lines []string
+	for _, process := range automation.Processes {
+		if process.LastGoalVersionAchieved >= automation.GoalVersion {
+			continue
+		}
+
+		since, ok := previous.BehindSince[process.Name]
+		if !ok {
+			since = now
+		}
+		next.BehindSince[process.Name] = since
+
+		behind := now.Sub(since)
+		if behind > longest {
+			longest = behind
+		}
+
+		plan := "no plan"
+		if len(process.Plan) > 0 {
+			plan = "plan: " + strings.Join(process.Plan, ", ")
+		}
+		lines = append(lines, fmt.Sprintf("%v on %v: at version %v for %v minutes, %v",
+			process.Name, process.Hostname, process.LastGoalVersionAchieved, int(behind.Minutes()), plan))
+	}
+
+	note := ""
+	if err := store.Save(key, next); err != nil {
+		note = fmt.Sprintf(" (%v)", err)
+	}
+
+	check.AddPerfDatum("processes_behind", "", float64(len(lines)))
+	check.AddPerfDatum("minutes_behind", "", longest.Minutes())
+	if len(lines) == 0 {
+		check.AddResultf(nagiosplugin.OK, "All %v processes reached goal version %v%v", len(automation.Processes), automation.GoalVersion, note)
+		return
+	}
+
+	status, err := checkThresholds(longest.Minutes())
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	check.AddResultf(status, "%v of %v processes are behind goal version %v, for up to %v minutes%v\n%v",
+		len(lines), len(automation.Processes), automation.GoalVersion, int(longest.Minutes()), note, strings.Join(lines, "\n"))
+}
+
+func automationUsage() {
+	fmt.Fprintf(os.Stdout, "\n     automation mode: -w and -c are ranges of the minutes a process has been behind the automation goal, e.g. -w 15 -c 60\n")
+	fmt.Fprintf(os.Stdout, "     the time is counted from the first run that found the process behind, kept in --state-dir\n")
+}
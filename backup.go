@@ -0,0 +1,156 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"./model"
+	"./util"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/fractalcat/nagiosplugin"
+	"os"
+	"strings"
+	"time"
+)
+
+var backupCluster string
+var backupPITWindow int
+
+// doBackupCheck checks the backup of a replica set or sharded cluster: the
+// backup must be started, the age in hours of the newest complete snapshot
+// is checked against the warning and critical ranges, and with --pit-window
+// the point in time restore window available must be at least that many
+// hours: the configured window, or less if the oldest snapshot is newer.
+func doBackupCheck(check *nagiosplugin.Check, api *util.MMSAPI, host *model.Host) {
+	if backupCluster == "" {
+		check.AddResultf(nagiosplugin.UNKNOWN, "The backup mode requires --cluster name")
+		return
+	}
+
+	cluster, err := findCluster(api, backupCluster)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	config, err := api.GetBackupConfig(groupId, cluster.Id)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	switch config.StatusName {
+	case model.BackupStarted:
+	case model.BackupProvisioning:
+		check.AddResultf(nagiosplugin.WARNING, "Backup of %v is %v", cluster.Name(), config.StatusName)
+		return
+	default:
+		check.AddResultf(nagiosplugin.CRITICAL, "Backup of %v is %v", cluster.Name(), config.StatusName)
+		return
+	}
+
+	snapshots, err := api.GetSnapshots(groupId, cluster.Id)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	var newest, oldest *model.Snapshot
+	for i, snapshot := range snapshots {
+		if !snapshot.Complete {
+			continue
+		}
+
+		if newest == nil || snapshot.Created.Date.After(newest.Created.Date) {
+			newest = &snapshots[i]
+		}
+		if oldest == nil || snapshot.Created.Date.Before(oldest.Created.Date) {
+			oldest = &snapshots[i]
+		}
+	}
+
+	if newest == nil {
+		check.AddResultf(nagiosplugin.CRITICAL, "No complete snapshots of %v found", cluster.Name())
+		return
+	}
+
+	age := time.Since(newest.Created.Date).Hours()
+	check.AddPerfDatum("snapshot_age_hours", "", age)
+
+	status, err := checkThresholds(age)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	messages := []string{fmt.Sprintf("Newest snapshot of %v is %.1f hours old (%v)", cluster.Name(), age, newest.Created.Date.Format(time.RFC3339))}
+	if newest.IsPossiblyInconsistent {
+		messages = append(messages, "it is possibly inconsistent")
+	}
+
+	if backupPITWindow > 0 {
+		schedule, err := api.GetSnapshotSchedule(groupId, cluster.Id)
+		if err != nil {
+			check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+			return
+		}
+
+		// A point in time restore starts from a snapshot, so after a resync
+		// the window only reaches back to the oldest snapshot taken since,
+		// however long the configured window is.
+		window := time.Since(oldest.Created.Date).Hours()
+		limit := fmt.Sprintf("the oldest snapshot is from %v", oldest.Created.Date.Format(time.RFC3339))
+		if configured := float64(schedule.PointInTimeWindowHours); configured < window {
+			window = configured
+			limit = "the configured window"
+		}
+
+		check.AddPerfDatum("pit_window_hours", "", window)
+		if window < float64(backupPITWindow) {
+			if statusSeverity(status) < statusSeverity(nagiosplugin.WARNING) {
+				status = nagiosplugin.WARNING
+			}
+			messages = append(messages, fmt.Sprintf("the point in time window is %.1f hours, less than %v (%v)", window, backupPITWindow, limit))
+		}
+	}
+
+	check.AddResult(status, strings.Join(messages, ", "))
+}
+
+// findCluster returns the replica set or sharded cluster with the name or
+// ID. The replica sets of the shards of a sharded cluster aren't backed up
+// on their own, so they aren't considered.
+func findCluster(api *util.MMSAPI, name string) (*model.Cluster, error) {
+	clusters, err := api.GetClusters(groupId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cluster := range clusters {
+		if cluster.ShardName == "" && (cluster.Id == name || cluster.Name() == name || cluster.ReplicaSetName == name) {
+			return &cluster, nil
+		}
+	}
+
+	return nil, errors.New(fmt.Sprintf("No replica set or sharded cluster named %v found", name))
+}
+
+const (
+	backupClusterUsage     = "name or ID of the replica set or sharded cluster whose backup is checked"
+	backupPITWindowDefault = 0
+	backupPITWindowUsage   = "hours the point in time restore window available must be at least"
+)
+
+func setupBackupFlags() {
+	flag.StringVar(&backupCluster, "cluster", "", backupClusterUsage)
+	flag.IntVar(&backupPITWindow, "pit-window", backupPITWindowDefault, backupPITWindowUsage)
+}
+
+func backupUsage() {
+	fmt.Fprintf(os.Stdout, "\n     backup mode: -w and -c are ranges of the age in hours of the newest complete snapshot, e.g. -w 24 -c 48\n")
+	fmt.Fprintf(os.Stdout, "     --cluster %v\n", backupClusterUsage)
+	fmt.Fprintf(os.Stdout, "     --pit-window (default: %v) %v\n", backupPITWindowDefault, backupPITWindowUsage)
+}
@@ -0,0 +1,198 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"./model"
+	"./util"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/fractalcat/nagiosplugin"
+	"math"
+	"os"
+	"time"
+)
+
+const (
+	BaselineGranularity = "MINUTE"
+
+	// MinuteRetention and HourRetention are how long MMS/Ops Manager keeps
+	// MINUTE and HOUR data points.
+	MinuteRetention = 48 * time.Hour
+	HourRetention   = 63 * 24 * time.Hour
+
+	// MinStdDevPoints is the fewest baseline data points a standard
+	// deviation is taken of.
+	MinStdDevPoints = 2
+
+	DeviationPercent = "percent"
+	DeviationStdDev  = "stddev"
+)
+
+var aggregates = map[string]func([]float64) float64{
+	"mean":   util.Mean,
+	"median": util.Median,
+	"max":    util.Max,
+}
+
+var baselineDays int
+var baselineWindow int
+var baselineAggregate string
+var baselineDeviation string
+
+// doBaselineCheck compares the aggregate of the metric over the last window
+// with the same window baselineDays ago, and checks the deviation from the
+// baseline against the warning and critical ranges. The deviation is signed,
+// so a range such as -50:50 alerts on both drops and spikes.
+func doBaselineCheck(check *nagiosplugin.Check, api *util.MMSAPI, host *model.Host) {
+	if metricName == "" {
+		check.AddResultf(nagiosplugin.UNKNOWN, "The baseline mode requires -m metric")
+		return
+	}
+
+	aggregate, ok := aggregates[baselineAggregate]
+	if !ok {
+		check.AddResultf(nagiosplugin.UNKNOWN, "Unknown aggregate %v", baselineAggregate)
+		return
+	}
+
+	if baselineDeviation != DeviationPercent && baselineDeviation != DeviationStdDev {
+		check.AddResultf(nagiosplugin.UNKNOWN, "Unknown deviation %v", baselineDeviation)
+		return
+	}
+
+	if err := validateMetric(api, groupId, host.Id, metricName, dbName, partition); err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	end := time.Now()
+	start := end.Add(-time.Duration(baselineWindow) * time.Minute)
+	offset := time.Duration(baselineDays) * 24 * time.Hour
+
+	currentQuery := util.MetricQuery{Granularity: granularityOr(BaselineGranularity), Start: start, End: end}
+	current, err := getMetricHistory(api, groupId, host.Id, metricName, dbName, partition, currentQuery)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	baselineStart := start.Add(-offset)
+	baselineQuery := util.MetricQuery{Granularity: granularityOr(baselineGranularity(end.Sub(baselineStart))), Start: baselineStart, End: end.Add(-offset)}
+	baseline, err := getMetricHistory(api, groupId, host.Id, metricName, dbName, partition, baselineQuery)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	if len(current.DataPoints) == 0 || len(baseline.DataPoints) == 0 {
+		check.AddResultf(nagiosplugin.UNKNOWN, "No data points found for %v in the current or baseline window", metricName)
+		return
+	}
+
+	currentValue, baselineValue, deviation, err := compareWithBaseline(util.Values(current.DataPoints), util.Values(baseline.DataPoints), aggregate)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	unit := "%"
+	if baselineDeviation == DeviationStdDev {
+		unit = " standard deviations"
+	}
+
+	check.AddPerfDatum(metricName, "", currentValue)
+	check.AddPerfDatum("baseline", "", baselineValue)
+	if !math.IsInf(deviation, 0) {
+		check.AddPerfDatum("deviation", "", deviation)
+	}
+
+	status, err := checkThresholds(deviation)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	check.AddResultf(status, "%v %v over the last %v minutes is %v, %+.1f%v from %v %v days ago",
+		baselineAggregate, metricName, baselineWindow, currentValue, deviation, unit, baselineValue, baselineDays)
+}
+
+// compareWithBaseline aggregates the current and baseline values, and returns
+// them with the deviation of the current value from the baseline in the unit
+// of --deviation. A standard deviation needs at least MinStdDevPoints
+// baseline values, as that of a single value is 0 and any change would be
+// infinitely large.
+func compareWithBaseline(currentValues []float64, baselineValues []float64, aggregate func([]float64) float64) (float64, float64, float64, error) {
+	currentValue := aggregate(currentValues)
+	baselineValue := aggregate(baselineValues)
+
+	if baselineDeviation != DeviationStdDev {
+		return currentValue, baselineValue, ratio(currentValue-baselineValue, math.Abs(baselineValue)) * 100, nil
+	}
+
+	if len(baselineValues) < MinStdDevPoints {
+		return 0, 0, 0, errors.New(fmt.Sprintf("Only %v data points found in the baseline window, at least %v are needed for --deviation %v; use a longer --baseline-window or a finer -G",
+			len(baselineValues), MinStdDevPoints, DeviationStdDev))
+	}
+
+	// Measured from the aggregate the message shows, so that with median or
+	// max both agree on what the current value is compared with.
+	return currentValue, baselineValue, ratio(currentValue-baselineValue, util.StdDev(baselineValues)), nil
+}
+
+// baselineGranularity returns the finest granularity MMS/Ops Manager still
+// keeps data points of the given age in.
+func baselineGranularity(age time.Duration) string {
+	switch {
+	case age <= MinuteRetention:
+		return BaselineGranularity
+	case age <= HourRetention:
+		return "HOUR"
+	}
+
+	return "DAY"
+}
+
+// ratio divides a difference by a scale, treating any difference from a
+// scale of zero as infinitely large.
+func ratio(difference float64, scale float64) float64 {
+	if scale != 0 {
+		return difference / scale
+	}
+
+	if difference == 0 {
+		return 0
+	}
+
+	return math.Inf(int(math.Copysign(1, difference)))
+}
+
+const (
+	baselineDaysDefault      = 7
+	baselineDaysUsage        = "days ago the baseline window is taken from"
+	baselineWindowDefault    = 60
+	baselineWindowUsage      = "minutes of data points in the current and baseline windows"
+	baselineAggregateDefault = "mean"
+	baselineAggregateUsage   = "how the data points of a window are aggregated: mean, median or max"
+	baselineDeviationDefault = DeviationPercent
+	baselineDeviationUsage   = "unit of the deviation from the baseline: percent or stddev"
+)
+
+func setupBaselineFlags() {
+	flag.IntVar(&baselineDays, "baseline-days", baselineDaysDefault, baselineDaysUsage)
+	flag.IntVar(&baselineWindow, "baseline-window", baselineWindowDefault, baselineWindowUsage)
+	flag.StringVar(&baselineAggregate, "aggregate", baselineAggregateDefault, baselineAggregateUsage)
+	flag.StringVar(&baselineDeviation, "deviation", baselineDeviationDefault, baselineDeviationUsage)
+}
+
+func baselineUsage() {
+	fmt.Fprintf(os.Stdout, "\n     baseline mode: -w and -c are ranges of the signed deviation from the baseline, e.g. -w -50:50 -c -80:100\n")
+	fmt.Fprintf(os.Stdout, "     data points have a granularity of %v unless -G is given, baseline windows older than MMS/Ops Manager keeps those for use HOUR or DAY\n", BaselineGranularity)
+	fmt.Fprintf(os.Stdout, "     --baseline-days (default: %v) %v\n", baselineDaysDefault, baselineDaysUsage)
+	fmt.Fprintf(os.Stdout, "     --baseline-window (default: %v) %v\n", baselineWindowDefault, baselineWindowUsage)
+	fmt.Fprintf(os.Stdout, "     --aggregate (default: %v) %v\n", baselineAggregateDefault, baselineAggregateUsage)
+	fmt.Fprintf(os.Stdout, "     --deviation (default: %v) %v\n", baselineDeviationDefault, baselineDeviationUsage)
+}
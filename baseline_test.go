@@ -0,0 +1,74 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"./util"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBaselineGranularity(t *testing.T) {
+	tests := []struct {
+		days        int
+		window      int
+		granularity string
+	}{
+		{1, 60, "MINUTE"},
+		{2, 0, "MINUTE"},
+		{2, 60, "HOUR"},
+		{7, 60, "HOUR"},
+		{63, 0, "HOUR"},
+		{63, 60, "DAY"},
+		{365, 60, "DAY"},
+	}
+
+	for _, test := range tests {
+		age := time.Duration(test.days)*24*time.Hour + time.Duration(test.window)*time.Minute
+		if granularity := baselineGranularity(age); granularity != test.granularity {
+			t.Errorf("%v days and %v minutes ago: %v, expected %v", test.days, test.window, granularity, test.granularity)
+		}
+	}
+}
+
+func TestCompareWithBaseline(t *testing.T) {
+	defer func(deviation string) { baselineDeviation = deviation }(baselineDeviation)
+
+	tests := []struct {
+		deviation string
+		aggregate string
+		current   []float64
+		baseline  []float64
+		expected  float64
+		fails     bool
+	}{
+		{DeviationPercent, "mean", []float64{150, 150}, []float64{100, 100}, 50, false},
+		{DeviationPercent, "mean", []float64{50}, []float64{100}, -50, false},
+		{DeviationPercent, "mean", []float64{1}, []float64{0}, math.Inf(1), false},
+		{DeviationStdDev, "mean", []float64{14}, []float64{8, 12}, 2, false},
+		{DeviationStdDev, "median", []float64{20}, []float64{10, 10, 10, 30}, (20 - 10) / util.StdDev([]float64{10, 10, 10, 30}), false},
+		{DeviationStdDev, "max", []float64{30}, []float64{10, 20}, 2, false},
+		{DeviationStdDev, "mean", []float64{14}, []float64{10, 10}, math.Inf(1), false},
+		// A single HOUR data point in a 60 minute baseline window has no
+		// spread to measure the deviation in.
+		{DeviationStdDev, "mean", []float64{14}, []float64{10}, 0, true},
+	}
+
+	for _, test := range tests {
+		baselineDeviation = test.deviation
+		_, _, deviation, err := compareWithBaseline(test.current, test.baseline, aggregates[test.aggregate])
+		if test.fails {
+			if err == nil {
+				t.Errorf("%v of %v against %v: expected an error, got %v", test.deviation, test.current, test.baseline, deviation)
+			}
+			continue
+		}
+
+		if err != nil || (deviation != test.expected && math.Abs(deviation-test.expected) > 1e-9) {
+			t.Errorf("%v of %v against %v: %v (%v), expected %v", test.deviation, test.current, test.baseline, deviation, err, test.expected)
+		}
+	}
+}
@@ -7,10 +7,12 @@ package main
 import (
 	"./model"
 	"./util"
+	"errors"
 	"flag"
 	"fmt"
 	"github.com/fractalcat/nagiosplugin"
 	"os"
+	"strings"
 	"time"
 )
 
@@ -22,15 +24,77 @@ var groupId string
 var hostname string
 var metricName string
 var dbName string
+var partition string
 var server string
 var warning string
 var critical string
 var timeout int
 var maxAge int
+var filter string
+var mode string
+var granularity string
+var rate string
+var expression string
+var replicaSet string
+
+// subcommands maps the first command line argument to a tool that is not a
+// Nagios check. Anything else is treated as the flags of a check.
+var subcommands = map[string]func(args []string){
+	"ack":         runAck,
+	"generate":    runGenerate,
+	"list":        runList,
+	"maintenance": runMaintenance,
+	"zabbix":      runZabbix,
+}
+
+const (
+	ModeMetric     = "metric"
+	ModeForecast   = "forecast"
+	ModeBaseline   = "baseline"
+	ModeAnomaly    = "anomaly"
+	ModeAlerts     = "alerts"
+	ModeAudit      = "audit"
+	ModeBackup     = "backup"
+	ModeAutomation = "automation"
+	ModeAgents     = "agents"
+	ModeEvents     = "events"
+	ModeElection   = "election"
+	ModeVersion    = "version"
+)
+
+// checkMode is a kind of check selected with -M. Checks of the whole group
+// don't need a host and are run with a nil host.
+type checkMode struct {
+	needsHost bool
+	run       func(check *nagiosplugin.Check, api *util.MMSAPI, host *model.Host)
+}
+
+var checkModes = map[string]checkMode{
+	ModeMetric:     {true, doDefaultCheck},
+	ModeForecast:   {true, doForecastCheck},
+	ModeBaseline:   {true, doBaselineCheck},
+	ModeAnomaly:    {true, doAnomalyCheck},
+	ModeAlerts:     {false, doAlertsCheck},
+	ModeAudit:      {false, doAuditCheck},
+	ModeBackup:     {false, doBackupCheck},
+	ModeAutomation: {false, doAutomationCheck},
+	ModeAgents:     {false, doAgentsCheck},
+	ModeEvents:     {false, doEventsCheck},
+	ModeElection:   {false, doElectionCheck},
+	ModeVersion:    {false, doVersionCheck},
+}
 
 func main() {
+	if len(os.Args) > 1 {
+		if subcommand, ok := subcommands[os.Args[1]]; ok {
+			subcommand(os.Args[2:])
+			return
+		}
+	}
+
 	setupFlags()
-	if hostname == "" || groupId == "" {
+	checkMode, ok := checkModes[mode]
+	if !ok || groupId == "" || (checkMode.needsHost && hostname == "") {
 		flag.Usage()
 		os.Exit(2)
 		return
@@ -39,26 +103,67 @@ func main() {
 	check := nagiosplugin.NewCheck()
 	defer check.Finish()
 
-	config, err := util.LoadConfigFromHome(CredFile)
+	api, err := newAPI(server, timeout)
 	if err != nil {
 		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
 		return
 	}
 
-	username, apikey := config.GetCredentials()
-	api, err := util.NewMMSAPI(server, timeout, username, apikey)
+	// Without an active monitoring agent every check of the group fails at
+	// once, which is reported once by the agents mode instead.
+	if requireAgent && mode != ModeAgents {
+		down, err := monitoringAgentDown(api)
+		if err != nil {
+			check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+			return
+		}
+
+		if down {
+			check.AddResult(nagiosplugin.UNKNOWN, "monitoring agent down")
+			return
+		}
+	}
+
+	var host *model.Host
+	if checkMode.needsHost {
+		if host, err = api.GetHostByName(groupId, hostname); err != nil {
+			check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+			return
+		}
+	}
+
+	checkMode.run(check, api, host)
+}
+
+// newAPI loads the credentials from the user's home directory and creates
+// a client for the given MMS/Ops Manager server.
+func newAPI(server string, timeout int) (*util.MMSAPI, error) {
+	config, err := util.LoadConfigFromHome(CredFile)
 	if err != nil {
-		check.AddResultf(nagiosplugin.UNKNOWN, "Failed to create API. Error: %v", err)
-		return
+		return nil, err
 	}
 
-	host, err := api.GetHostByName(groupId, hostname)
+	username, apikey := config.GetCredentials()
+	api, err := util.NewMMSAPI(server, timeout, username, apikey)
 	if err != nil {
-		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
-		return
+		return nil, errors.New(fmt.Sprintf("Failed to create API. Error: %v", err))
 	}
 
-	if metricName == "" {
+	return api, nil
+}
+
+// exitWithError reports an error from a subcommand and exits non-zero.
+func exitWithError(err error) {
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	os.Exit(1)
+}
+
+// doDefaultCheck checks the metric or expression, or the age of the last
+// ping if neither was given.
+func doDefaultCheck(check *nagiosplugin.Check, api *util.MMSAPI, host *model.Host) {
+	if expression != "" {
+		doExpressionCheck(check, api, host)
+	} else if metricName == "" {
 		doHostCheck(check, host)
 	} else {
 		doMetricCheck(check, api, host)
@@ -94,78 +199,258 @@ func doHostCheck(check *nagiosplugin.Check, host *model.Host) {
 }
 
 func doMetricCheck(check *nagiosplugin.Check, api *util.MMSAPI, host *model.Host) {
-	var metric *model.Metric
-	var err error
-	if dbName == "" {
-		metric, err = api.GetHostMetric(groupId, host.Id, metricName)
-	} else {
-		metric, err = api.GetHostDBMetric(groupId, host.Id, metricName, dbName)
+	if rate != "" && rate != model.RatePerSecond && rate != model.RatePerInterval {
+		check.AddResultf(nagiosplugin.UNKNOWN, "Unknown rate %v", rate)
+		return
+	}
+
+	if err := validateMetric(api, groupId, host.Id, metricName, dbName, partition); err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
 	}
 
+	skip, err := applyProfile(metricName, host, time.Now())
 	if err != nil {
 		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
 		return
 	}
 
-	if len(metric.DataPoints) == 0 {
-		check.AddResultf(nagiosplugin.UNKNOWN, "No data points found for %v", metricName)
+	if skip {
+		check.AddResult(nagiosplugin.OK, withProfile(fmt.Sprintf("%v does not apply to %v hosts", metricName, host.Role())))
+		return
+	}
+
+	if dbName == AllSeries || partition == AllSeries {
+		doAllMetricCheck(check, api, host)
+		return
+	}
+
+	metric, err := getMetric(api, groupId, host.Id, metricName, dbName, partition)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
 		return
 	}
 
+	result := checkMetric(metric)
+	if result.hasValue {
+		check.AddPerfDatum(metricName, "", result.value)
+
+		status, note, err := dampStatus(stateKey(host.Id, metricName), result.status, result.value)
+		if err != nil {
+			check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+			return
+		}
+
+		if note != "" {
+			result.status = status
+			result.message += " " + note
+		}
+	}
+
+	check.AddResult(result.status, withProfile(result.message))
+}
+
+// metricResult is the outcome of checking the last data point of a metric.
+type metricResult struct {
+	name     string
+	status   nagiosplugin.Status
+	message  string
+	value    float64
+	hasValue bool
+}
+
+// checkMetric checks that the last data point of the metric is fresh and
+// compares it against the warning and critical ranges. With -R the metric is
+// converted to a rate first.
+func checkMetric(metric *model.Metric) metricResult {
+	resets := 0
+	if rate != "" {
+		metric, resets = metric.Rate(rate)
+	}
+
+	if len(metric.DataPoints) == 0 {
+		return metricResult{status: nagiosplugin.UNKNOWN, message: fmt.Sprintf("No data points found for %v", metricName)}
+	}
+
 	lastDataPoint := metric.DataPoints[len(metric.DataPoints)-1]
 	age := time.Since(lastDataPoint.Timestamp)
 	if int(age.Seconds()) > maxAge {
-		check.AddResultf(nagiosplugin.CRITICAL, "Last data point for %v is %v seconds old.", metricName, int(age.Seconds()))
-		return
+		return metricResult{status: nagiosplugin.CRITICAL, message: fmt.Sprintf("Last data point for %v is %v seconds old.", metricName, int(age.Seconds()))}
+	}
+
+	result := metricResult{value: lastDataPoint.Value, hasValue: true}
+	status, err := checkThresholds(lastDataPoint.Value)
+	if err != nil {
+		result.status = nagiosplugin.UNKNOWN
+		result.message = err.Error()
+		return result
+	}
+
+	result.status = status
+	result.message = metric.ToStringLastDataPoint() + rateNote(resets, metric.MetricName)
+	return result
+}
+
+// rateNote returns the note added to the message of a check of the metrics:
+// how often their counters were reset with -R, or which of them count since
+// the process started and are checked without -R.
+func rateNote(resets int, metricNames ...string) string {
+	if rate != "" {
+		if resets > 0 {
+			return fmt.Sprintf(" (counter reset %v times by a process restart)", resets)
+		}
+		return ""
 	}
 
-	check.AddPerfDatum(metricName, "", lastDataPoint.Value)
+	var cumulative []string
+	for _, name := range metricNames {
+		if model.IsCumulativeMetric(name) {
+			cumulative = append(cumulative, name)
+		}
+	}
 
+	if len(cumulative) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" (%v counts since the process started, use -R to check the rate)", strings.Join(cumulative, ", "))
+}
+
+// checkThresholds returns the state of the value given the warning and
+// critical ranges.
+func checkThresholds(value float64) (nagiosplugin.Status, error) {
 	critRange, err := nagiosplugin.ParseRange(critical)
 	if err != nil {
-		check.AddResultf(nagiosplugin.UNKNOWN, "Error parsing critical range. Error: %v", err)
-		return
+		return nagiosplugin.UNKNOWN, errors.New(fmt.Sprintf("Error parsing critical range. Error: %v", err))
 	}
 
-	if critRange.Check(lastDataPoint.Value) {
-		check.AddResultf(nagiosplugin.CRITICAL, metric.ToStringLastDataPoint())
-		return
+	if critRange.Check(value) {
+		return nagiosplugin.CRITICAL, nil
 	}
 
 	warnRange, err := nagiosplugin.ParseRange(warning)
 	if err != nil {
-		check.AddResultf(nagiosplugin.UNKNOWN, "Error parsing warning range. Error: %v", err)
-		return
+		return nagiosplugin.UNKNOWN, errors.New(fmt.Sprintf("Error parsing warning range. Error: %v", err))
 	}
 
-	if warnRange.Check(lastDataPoint.Value) {
-		check.AddResultf(nagiosplugin.WARNING, metric.ToStringLastDataPoint())
-		return
+	if warnRange.Check(value) {
+		return nagiosplugin.WARNING, nil
+	}
+
+	return nagiosplugin.OK, nil
+}
+
+// validateMetric catches typos in the metric name, and a database name or
+// partition that is missing or given for the wrong kind of metric, before
+// they turn into an opaque API error. Metrics the plugin doesn't know are
+// checked against the metrics the host advertises. If that list can't be
+// read the metric is assumed valid.
+func validateMetric(api *util.MMSAPI, groupId string, hostId string, metricName string, dbName string, partition string) error {
+	if model.IsDBMetric(metricName) && dbName == "" {
+		return errors.New(fmt.Sprintf("%v is a database metric and requires -d dbname", metricName))
+	}
+
+	if !model.IsDBMetric(metricName) && dbName != "" {
+		return errors.New(fmt.Sprintf("%v is not a database metric and can not be used with -d", metricName))
+	}
+
+	if model.IsDiskMetric(metricName) && partition == "" {
+		return errors.New(fmt.Sprintf("%v is a disk partition metric and requires -p partition", metricName))
+	}
+
+	if !model.IsDiskMetric(metricName) && partition != "" {
+		return errors.New(fmt.Sprintf("%v is not a disk partition metric and can not be used with -p", metricName))
+	}
+
+	if model.IsKnownMetric(metricName) {
+		return nil
+	}
+
+	var advertised []model.Metric
+	var err error
+	if partition == "" {
+		advertised, err = api.GetHostMetrics(groupId, hostId)
+	} else {
+		advertised, err = api.GetHostDiskMetrics(groupId, hostId, partition)
+	}
+
+	if err != nil {
+		return nil
+	}
+
+	candidates := model.KnownMetricNames()
+	for _, metric := range advertised {
+		if metric.MetricName == metricName {
+			return nil
+		}
+		candidates = append(candidates, metric.MetricName)
+	}
+
+	if suggestion := util.Suggest(metricName, candidates); suggestion != "" {
+		return errors.New(fmt.Sprintf("unknown metric %v, did you mean %v?", metricName, suggestion))
+	}
+
+	return errors.New(fmt.Sprintf("unknown metric %v", metricName))
+}
+
+// granularityOr returns the granularity given with -G, or the mode's default.
+func granularityOr(modeDefault string) string {
+	if granularity == "" {
+		return modeDefault
+	}
+
+	return granularity
+}
+
+// getMetric fetches a host metric, a DB_ metric when dbName is given or a
+// DISK_PARTITION_ metric when partition is given.
+func getMetric(api *util.MMSAPI, groupId string, hostId string, metricName string, dbName string, partition string) (*model.Metric, error) {
+	return getMetricHistory(api, groupId, hostId, metricName, dbName, partition, util.MetricQuery{})
+}
+
+// getMetricHistory is getMetric for the data points selected by the query.
+func getMetricHistory(api *util.MMSAPI, groupId string, hostId string, metricName string, dbName string, partition string, query util.MetricQuery) (*model.Metric, error) {
+	if dbName != "" {
+		return api.GetHostDBMetric(groupId, hostId, metricName, dbName, query)
+	}
+
+	if partition != "" {
+		return api.GetHostDiskMetric(groupId, hostId, partition, metricName, query)
 	}
 
-	check.AddResultf(nagiosplugin.OK, metric.ToStringLastDataPoint())
+	return api.GetHostMetric(groupId, hostId, metricName, query)
 }
 
 func setupFlags() {
 	const (
-		groupIdDefault  = ""
-		groupIdUsage    = "The MMS/Ops Manager group ID that contains the server"
-		hostnameDefault = ""
-		hostnameUsage   = "hostname:port of the mongod/s to check"
-		metricDefault   = ""
-		metricUsage     = "metric to query"
-		dbNameDefault   = ""
-		dbNameUsage     = "database name for DB_ metrics"
-		serverDefault   = "https://mms.mongodb.com"
-		serverUsage     = "hostname and port of the MMS/Ops Manager service"
-		warningDefault  = "~:" // considered negative infinity to positive infinity (https://nagios-plugins.org/doc/guidelines.html#THRESHOLDFORMAT)
-		warningUsage    = "warning threshold for given metric"
-		criticalDefault = "~:"
-		criticalUsage   = "critical threshold for given metric"
-		timeoutDefault  = 10
-		timeoutUsage    = "connection timeout connecting MMS/Ops Manager service"
-		maxAgeDefault   = 360
-		maxAgeUsage     = "the maximum number of seconds old a metric before it is considerd stale"
+		groupIdDefault   = ""
+		groupIdUsage     = "The MMS/Ops Manager group ID that contains the server"
+		hostnameDefault  = ""
+		hostnameUsage    = "hostname:port of the mongod/s to check"
+		metricDefault    = ""
+		metricUsage      = "metric to query"
+		dbNameDefault    = ""
+		dbNameUsage      = "database name for DB_ metrics"
+		partitionDefault = ""
+		partitionUsage   = "disk partition name for DISK_PARTITION_ metrics"
+		filterDefault    = ""
+		filterUsage      = "regular expression the database or partition names must match when -d or -p is *"
+		serverDefault    = "https://mms.mongodb.com"
+		serverUsage      = "hostname and port of the MMS/Ops Manager service"
+		warningDefault   = "~:" // considered negative infinity to positive infinity (https://nagios-plugins.org/doc/guidelines.html#THRESHOLDFORMAT)
+		warningUsage     = "warning threshold for given metric"
+		criticalDefault  = "~:"
+		criticalUsage    = "critical threshold for given metric"
+		timeoutDefault   = 10
+		timeoutUsage     = "connection timeout connecting MMS/Ops Manager service"
+		maxAgeDefault    = 360
+		maxAgeUsage      = "the maximum number of seconds old a metric before it is considerd stale"
+		modeDefault      = ModeMetric
+		modeUsage        = "the kind of check: metric, forecast, baseline, anomaly, alerts, audit, backup, automation, agents, events, election or version"
+		expressionUsage  = "arithmetic expression of metrics to check instead of a single metric, e.g. \"CONNECTIONS / 20000 * 100\""
+		rateUsage        = "check the increase of a cumulative metric such as ASSERT_REGULAR per second or per interval between data points"
+		granularityUsage = "granularity of the data points of modes that query a history (MINUTE, FIVE_MINUTES, HOUR or DAY)"
+		replicaSetUsage  = "only check the replica set in modes that check the whole group"
 	)
 
 	flag.StringVar(&groupId, "groupid", groupIdDefault, groupIdUsage)
@@ -180,6 +465,12 @@ func setupFlags() {
 	flag.StringVar(&dbName, "dbname", dbNameDefault, dbNameUsage)
 	flag.StringVar(&dbName, "d", dbNameDefault, dbNameUsage)
 
+	flag.StringVar(&partition, "partition", partitionDefault, partitionUsage)
+	flag.StringVar(&partition, "p", partitionDefault, partitionUsage)
+
+	flag.StringVar(&filter, "filter", filterDefault, filterUsage)
+	flag.StringVar(&filter, "F", filterDefault, filterUsage)
+
 	flag.IntVar(&maxAge, "maxage", maxAgeDefault, maxAgeUsage)
 	flag.IntVar(&maxAge, "a", maxAgeDefault, maxAgeUsage)
 
@@ -195,19 +486,67 @@ func setupFlags() {
 	flag.IntVar(&timeout, "timeout", timeoutDefault, timeoutUsage)
 	flag.IntVar(&timeout, "t", timeoutDefault, timeoutUsage)
 
+	flag.StringVar(&mode, "mode", modeDefault, modeUsage)
+	flag.StringVar(&mode, "M", modeDefault, modeUsage)
+
+	flag.StringVar(&expression, "expression", "", expressionUsage)
+	flag.StringVar(&expression, "e", "", expressionUsage)
+
+	flag.StringVar(&rate, "rate", "", rateUsage)
+	flag.StringVar(&rate, "R", "", rateUsage)
+
+	flag.StringVar(&granularity, "granularity", "", granularityUsage)
+	flag.StringVar(&granularity, "G", "", granularityUsage)
+
+	flag.StringVar(&replicaSet, "replica-set", "", replicaSetUsage)
+
+	setupForecastFlags()
+	setupBaselineFlags()
+	setupAnomalyFlags()
+	setupHysteresisFlags()
+	setupProfileFlags()
+	setupAlertsFlags()
+	setupAuditFlags()
+	setupBackupFlags()
+	setupAgentsFlags()
+	setupEventsFlags()
+	setupElectionFlags()
+	setupVersionFlags()
+
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stdout, "Usage: check_mongodb_mms  -g groupid -H hostname [-m metric] [-d dbname] [-a age] [-s server] [-t timeout] [-w warning_level] [-c critica_level]\n")
+		fmt.Fprintf(os.Stdout, "Usage: check_mongodb_mms  -g groupid -H hostname [-M mode] [-m metric | -e expression] [-d dbname] [-p partition] [-F filter] [-R second|interval] [-a age] [-s server] [-t timeout] [-w warning_level] [-c critica_level]\n")
+		fmt.Fprintf(os.Stdout, "     -M, --mode (default: %v) %v\n", modeDefault, modeUsage)
 		fmt.Fprintf(os.Stdout, "     -g, --groupid  %v\n", groupIdUsage)
 		fmt.Fprintf(os.Stdout, "     -H, --hostname %v\n", hostnameUsage)
 		fmt.Fprintf(os.Stdout, "     -m, --metric (no metric means check last ping age in seconds) %v\n", metricUsage)
+		fmt.Fprintf(os.Stdout, "     -e, --expression %v\n", expressionUsage)
 		fmt.Fprintf(os.Stdout, "     -d, --dbname (default %v) %v\n", dbNameDefault, dbNameUsage)
+		fmt.Fprintf(os.Stdout, "     -p, --partition (default %v) %v\n", partitionDefault, partitionUsage)
+		fmt.Fprintf(os.Stdout, "     -F, --filter %v\n", filterUsage)
+		fmt.Fprintf(os.Stdout, "     -R, --rate %v\n", rateUsage)
 		fmt.Fprintf(os.Stdout, "     -a, --maxage (default %v) %v\n", maxAgeDefault, maxAgeUsage)
 		fmt.Fprintf(os.Stdout, "     -s, --server (default: %v) %v\n", serverDefault, serverUsage)
 		fmt.Fprintf(os.Stdout, "     -w, --warning (default: %v) %v\n", warningDefault, warningUsage)
 		fmt.Fprintf(os.Stdout, "     -c, --critical (default: %v) %v\n", criticalDefault, criticalUsage)
 		fmt.Fprintf(os.Stdout, "     -t, --timeout (default: %v) %v\n", timeoutDefault, timeoutUsage)
+		fmt.Fprintf(os.Stdout, "     -G, --granularity %v\n", granularityUsage)
+		fmt.Fprintf(os.Stdout, "     --replica-set %v\n", replicaSetUsage)
 		fmt.Fprintf(os.Stdout, "\n     -w and -c support the standard nagios threshold formats.\n"+
 			"     See https://nagios-plugins.org/doc/guidelines.html#THRESHOLDFORMAT for more details.\n")
+		fmt.Fprintf(os.Stdout, "\n     -d * and -p * check every database or partition of the host and report the worst.\n")
+		forecastUsage()
+		baselineUsage()
+		anomalyUsage()
+		hysteresisUsage()
+		profileUsage()
+		alertsUsage()
+		auditUsage()
+		backupUsage()
+		automationUsage()
+		agentsUsage()
+		eventsUsage()
+		electionUsage()
+		versionUsage()
 	}
 	flag.Parse()
 }
@@ -0,0 +1,170 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"./model"
+	"./util"
+	"flag"
+	"fmt"
+	"github.com/fractalcat/nagiosplugin"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+var electionPeriod int
+
+// primaryState is the primary of a replica set the last time it was seen,
+// and the primary before it if it changed.
+type primaryState struct {
+	PrimaryId    string    `json:"primaryId"`
+	PrimaryName  string    `json:"primaryName"`
+	PreviousName string    `json:"previousName,omitempty"`
+	ChangedAt    time.Time `json:"changedAt,omitempty"`
+}
+
+// electionState is what the election check remembers for each replica set of
+// the group.
+type electionState struct {
+	ReplicaSets map[string]primaryState `json:"replicaSets"`
+}
+
+// doElectionCheck remembers the primary of every replica set of the group,
+// or only of --replica-set, and is a WARNING for --election-period minutes
+// after one changes. A replica set without a primary is CRITICAL. The time of
+// a change is when the check first saw the new primary, and a replica set
+// seen for the first time doesn't count as a change.
+func doElectionCheck(check *nagiosplugin.Check, api *util.MMSAPI, host *model.Host) {
+	hosts, err := api.GetAllHosts(groupId)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	dir, err := stateDirPath()
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	primaries := findPrimaries(hosts)
+	now := time.Now()
+	seen, err := trackPrimaries(util.NewStateStore(dir), primaries, now)
+	note := ""
+	if err != nil {
+		note = fmt.Sprintf(" (%v)", err)
+	}
+
+	names := make([]string, 0, len(primaries))
+	for name := range primaries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	period := time.Duration(electionPeriod) * time.Minute
+	status := nagiosplugin.OK
+	elections, missing := 0, 0
+	var lines []string
+	for _, name := range names {
+		current := seen.ReplicaSets[name]
+		if primaries[name] == nil {
+			missing++
+			status = nagiosplugin.CRITICAL
+			lines = append(lines, fmt.Sprintf("%v has no primary, the last one seen was %v", name, current.PrimaryName))
+			continue
+		}
+
+		if !current.ChangedAt.IsZero() && now.Sub(current.ChangedAt) < period {
+			elections++
+			if status == nagiosplugin.OK {
+				status = nagiosplugin.WARNING
+			}
+			lines = append(lines, fmt.Sprintf("%v primary changed from %v to %v at %v", name,
+				current.PreviousName, current.PrimaryName, current.ChangedAt.Format(time.RFC3339)))
+		}
+	}
+
+	check.AddPerfDatum("replica_sets", "", float64(len(names)))
+	check.AddPerfDatum("elections", "", float64(elections))
+	if len(lines) == 0 {
+		check.AddResultf(nagiosplugin.OK, "No primary of %v replica sets changed in the last %v minutes%v", len(names), electionPeriod, note)
+		return
+	}
+
+	summary := fmt.Sprintf("%v of %v replica sets changed primary in the last %v minutes", elections, len(names), electionPeriod)
+	if missing > 0 {
+		summary = fmt.Sprintf("%v of %v replica sets have no primary, %v changed primary in the last %v minutes", missing, len(names), elections, electionPeriod)
+	}
+
+	check.AddResultf(status, "%v%v\n%v", summary, note, strings.Join(lines, "\n"))
+}
+
+// findPrimaries returns the primary of every replica set of the hosts, or
+// only of --replica-set, which is nil for a replica set without one.
+func findPrimaries(hosts []model.Host) map[string]*model.Host {
+	primaries := make(map[string]*model.Host)
+	for i, member := range hosts {
+		if member.ReplicaSetName == "" || (replicaSet != "" && member.ReplicaSetName != replicaSet) {
+			continue
+		}
+
+		if _, ok := primaries[member.ReplicaSetName]; !ok {
+			primaries[member.ReplicaSetName] = nil
+		}
+
+		if member.ReplicaStateName == "PRIMARY" || member.Role() == model.RolePrimary {
+			primaries[member.ReplicaSetName] = &hosts[i]
+		}
+	}
+
+	return primaries
+}
+
+// trackPrimaries compares the primaries with the ones the last run saw, and
+// stores and returns what this run saw. A replica set without a primary keeps
+// the last one seen. Checks of a single --replica-set keep their own state,
+// so a service per replica set doesn't overwrite the others. The state is
+// returned even if it can't be stored.
+func trackPrimaries(store *util.StateStore, primaries map[string]*model.Host, now time.Time) (electionState, error) {
+	key := strings.Join([]string{ModeElection, groupId, replicaSet}, "/")
+	var previous electionState
+	store.Load(key, &previous)
+
+	next := electionState{ReplicaSets: make(map[string]primaryState)}
+	for name, primary := range primaries {
+		seen, known := previous.ReplicaSets[name]
+		switch {
+		case primary == nil:
+			if known {
+				next.ReplicaSets[name] = seen
+			}
+		case !known:
+			next.ReplicaSets[name] = primaryState{PrimaryId: primary.Id, PrimaryName: primary.Name()}
+		case seen.PrimaryId != primary.Id:
+			next.ReplicaSets[name] = primaryState{PrimaryId: primary.Id, PrimaryName: primary.Name(), PreviousName: seen.PrimaryName, ChangedAt: now}
+		default:
+			next.ReplicaSets[name] = seen
+		}
+	}
+
+	return next, store.Save(key, next)
+}
+
+const (
+	electionPeriodDefault = 60
+	electionPeriodUsage   = "minutes a change of primary is reported for"
+)
+
+func setupElectionFlags() {
+	flag.IntVar(&electionPeriod, "election-period", electionPeriodDefault, electionPeriodUsage)
+}
+
+func electionUsage() {
+	fmt.Fprintf(os.Stdout, "\n     election mode: WARNING after the primary of a replica set changed, CRITICAL without a primary\n")
+	fmt.Fprintf(os.Stdout, "     --election-period (default: %v) %v\n", electionPeriodDefault, electionPeriodUsage)
+	fmt.Fprintf(os.Stdout, "     the primaries are kept in --state-dir\n")
+}
@@ -0,0 +1,123 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"./model"
+	"./util"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+// members returns the hosts of replica sets rs0 and rs1, with the primary of
+// each given by its hostname.
+func members(primary0 string, primary1 string) []model.Host {
+	member := func(hostname string, name string, primary string) model.Host {
+		state := "SECONDARY"
+		if hostname == primary {
+			state = "PRIMARY"
+		}
+		return model.Host{Id: hostname, Hostname: hostname, Port: 27017, ReplicaSetName: name, ReplicaStateName: state}
+	}
+
+	return []model.Host{
+		member("a", "rs0", primary0), member("b", "rs0", primary0),
+		member("c", "rs1", primary1), member("d", "rs1", primary1),
+		{Id: "e", Hostname: "e", Port: 27017, TypeName: "STANDALONE"},
+	}
+}
+
+func TestTrackPrimariesOfFilteredChecks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "election")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(saved string) { replicaSet = saved }(replicaSet)
+	store := util.NewStateStore(dir)
+	start := time.Date(2015, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// One service per replica set, each run once a minute, one after the
+	// other. The primary of rs0 changes before the third round.
+	rounds := []struct {
+		primary0, primary1 string
+	}{
+		{"a", "c"},
+		{"a", "c"},
+		{"b", "c"},
+		{"b", "c"},
+	}
+
+	for i, round := range rounds {
+		now := start.Add(time.Duration(i) * time.Minute)
+		for _, name := range []string{"rs0", "rs1"} {
+			replicaSet = name
+			primaries := findPrimaries(members(round.primary0, round.primary1))
+			if len(primaries) != 1 || primaries[name] == nil {
+				t.Fatalf("round %v, %v: found primaries %v", i, name, primaries)
+			}
+
+			seen, err := trackPrimaries(store, primaries, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			state := seen.ReplicaSets[name]
+			if state.PrimaryId != primaries[name].Id {
+				t.Errorf("round %v, %v: primary %v, expected %v", i, name, state.PrimaryId, primaries[name].Id)
+			}
+
+			changed := name == "rs0" && i >= 2
+			if changed != !state.ChangedAt.IsZero() {
+				t.Errorf("round %v, %v: %+v, expected a change: %v", i, name, state, changed)
+			}
+			if changed && (!state.ChangedAt.Equal(start.Add(2*time.Minute)) || state.PreviousName != "a:27017") {
+				t.Errorf("round %v, %v: %+v, expected a change from a:27017 in round 2", i, name, state)
+			}
+		}
+	}
+}
+
+func TestTrackPrimariesWithoutPrimary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "election")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(saved string) { replicaSet = saved }(replicaSet)
+	replicaSet = ""
+	store := util.NewStateStore(dir)
+	now := time.Date(2015, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	if _, err := trackPrimaries(store, findPrimaries(members("a", "c")), now); err != nil {
+		t.Fatal(err)
+	}
+
+	primaries := findPrimaries(members("", "c"))
+	if len(primaries) != 2 || primaries["rs0"] != nil {
+		t.Fatalf("found primaries %v, expected none for rs0", primaries)
+	}
+
+	seen, err := trackPrimaries(store, primaries, now.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state := seen.ReplicaSets["rs0"]; state.PrimaryName != "a:27017" || !state.ChangedAt.IsZero() {
+		t.Errorf("rs0 without a primary: %+v, expected the last primary seen", state)
+	}
+
+	// The same primary coming back isn't an election.
+	seen, err = trackPrimaries(store, findPrimaries(members("a", "c")), now.Add(2*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state := seen.ReplicaSets["rs0"]; !state.ChangedAt.IsZero() {
+		t.Errorf("rs0 primary back: %+v, expected no change", state)
+	}
+}
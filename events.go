@@ -0,0 +1,168 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"./model"
+	"./util"
+	"flag"
+	"fmt"
+	"github.com/fractalcat/nagiosplugin"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxEventLines is the most events listed in the long output. Every
+	// event is still read and counted.
+	MaxEventLines = 50
+)
+
+var eventStates string
+var eventLookback int
+
+// eventCursor is where the last run stopped reading the events of the group:
+// the time the newest event was created, and the IDs of the events created at
+// that time, as the next run reads them again.
+type eventCursor struct {
+	Time time.Time `json:"time"`
+	Ids  []string  `json:"ids"`
+}
+
+// doEventsCheck reports the events of the group created since the last run.
+// Events are mapped to states by their type, and events of types that aren't
+// mapped are ignored. The cursor is only moved past events that have been
+// read, and is replaced atomically, so no event is reported twice. All events
+// since the cursor are read, however many there are, and only the long output
+// is cut short. Without a cursor the check starts --lookback minutes ago.
+func doEventsCheck(check *nagiosplugin.Check, api *util.MMSAPI, host *model.Host) {
+	states, err := parseSeverity(eventStates)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	dir, err := stateDirPath()
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	// Checks of different hosts or event types each have their own cursor, so
+	// they don't take each other's events.
+	store := util.NewStateStore(dir)
+	key := strings.Join([]string{ModeEvents, groupId, hostname, eventStates}, "/")
+	var cursor eventCursor
+	if !store.Load(key, &cursor) {
+		cursor = eventCursor{Time: time.Now().Add(-time.Duration(eventLookback) * time.Minute)}
+	}
+
+	events, err := api.GetEvents(groupId, cursor.Time)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, id := range cursor.Ids {
+		seen[id] = true
+	}
+
+	var fresh []model.Event
+	for _, event := range events {
+		if event.Created.After(cursor.Time) || (event.Created.Equal(cursor.Time) && !seen[event.Id]) {
+			fresh = append(fresh, event)
+		}
+	}
+	sort.SliceStable(fresh, func(i, j int) bool {
+		return fresh[i].Created.Before(fresh[j].Created)
+	})
+
+	status := nagiosplugin.OK
+	next := cursor
+	counts := make(map[string]int)
+	var types []string
+	var lines []string
+	for _, event := range fresh {
+		if event.Created.After(next.Time) {
+			next = eventCursor{Time: event.Created}
+		}
+		next.Ids = append(next.Ids, event.Id)
+
+		if hostname != "" && fmt.Sprintf("%v:%v", event.Hostname, event.Port) != hostname {
+			continue
+		}
+
+		eventStatus, ok := eventState(event, states)
+		if !ok {
+			continue
+		}
+
+		if statusSeverity(eventStatus) > statusSeverity(status) {
+			status = eventStatus
+		}
+
+		if counts[event.EventTypeName] == 0 {
+			types = append(types, event.EventTypeName)
+		}
+		counts[event.EventTypeName]++
+		lines = append(lines, fmt.Sprintf("%v: %v", eventStatus, event))
+	}
+
+	note := ""
+	if err := store.Save(key, next); err != nil {
+		note = fmt.Sprintf(" (%v)", err)
+	}
+
+	counted := len(lines)
+	check.AddPerfDatum("new_events", "", float64(counted))
+	if counted == 0 {
+		check.AddResultf(nagiosplugin.OK, "No new events since %v%v", cursor.Time.Format(time.RFC3339), note)
+		return
+	}
+
+	summary := make([]string, len(types))
+	for i, typeName := range types {
+		summary[i] = fmt.Sprintf("%v %v", counts[typeName], typeName)
+	}
+
+	if len(lines) > MaxEventLines {
+		lines = append(lines[:MaxEventLines], fmt.Sprintf("and %v more events", len(lines)-MaxEventLines))
+	}
+
+	check.AddResultf(status, "%v new events: %v%v\n%v", counted, strings.Join(summary, ", "), note, strings.Join(lines, "\n"))
+}
+
+// eventState maps an event to a state by its type name, or the * key. It
+// returns false if the event isn't mapped.
+func eventState(event model.Event, states map[string]nagiosplugin.Status) (nagiosplugin.Status, bool) {
+	if status, ok := states[event.EventTypeName]; ok {
+		return status, true
+	}
+
+	status, ok := states[AlertAny]
+	return status, ok
+}
+
+const (
+	eventStatesDefault   = "PRIMARY_ELECTED=warning,HOST_RESTARTED=warning,JOINED_GROUP=warning"
+	eventStatesUsage     = "comma separated type=state pairs mapping the events to report to ok, warning, critical or unknown, * maps every other type"
+	eventLookbackDefault = 0
+	eventLookbackUsage   = "minutes before the first run that events are reported from"
+)
+
+func setupEventsFlags() {
+	flag.StringVar(&eventStates, "events", eventStatesDefault, eventStatesUsage)
+	flag.IntVar(&eventLookback, "lookback", eventLookbackDefault, eventLookbackUsage)
+}
+
+func eventsUsage() {
+	fmt.Fprintf(os.Stdout, "\n     events mode: reports the events of the group since the last run, -H only reports events of the host\n")
+	fmt.Fprintf(os.Stdout, "     --events (default: %v) %v\n", eventStatesDefault, eventStatesUsage)
+	fmt.Fprintf(os.Stdout, "     --lookback (default: %v) %v\n", eventLookbackDefault, eventLookbackUsage)
+	fmt.Fprintf(os.Stdout, "     the position in the events is kept in --state-dir\n")
+}
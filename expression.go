@@ -0,0 +1,110 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"./model"
+	"./util"
+	"fmt"
+	"github.com/fractalcat/nagiosplugin"
+	"strings"
+	"time"
+)
+
+// doExpressionCheck evaluates the expression at the latest time every metric
+// it uses has a data point, and checks the result against the warning and
+// critical ranges. DB_ metrics in the expression use -d and DISK_PARTITION_
+// metrics use -p.
+func doExpressionCheck(check *nagiosplugin.Check, api *util.MMSAPI, host *model.Host) {
+	if rate != "" && rate != model.RatePerSecond && rate != model.RatePerInterval {
+		check.AddResultf(nagiosplugin.UNKNOWN, "Unknown rate %v", rate)
+		return
+	}
+
+	parsed, err := util.ParseExpression(expression)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	if len(parsed.MetricNames()) == 0 {
+		check.AddResultf(nagiosplugin.UNKNOWN, "Expression %v does not use any metrics", parsed)
+		return
+	}
+
+	series := make(map[string][]model.DataPoint)
+	resets := 0
+	for _, name := range parsed.MetricNames() {
+		metricDBName, metricPartition := "", ""
+		if model.IsDBMetric(name) {
+			metricDBName = dbName
+		}
+		if model.IsDiskMetric(name) {
+			metricPartition = partition
+		}
+
+		if err := validateMetric(api, groupId, host.Id, name, metricDBName, metricPartition); err != nil {
+			check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+			return
+		}
+
+		metric, err := getMetric(api, groupId, host.Id, name, metricDBName, metricPartition)
+		if err != nil {
+			check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+			return
+		}
+
+		if rate != "" {
+			var metricResets int
+			metric, metricResets = metric.Rate(rate)
+			resets += metricResets
+		}
+		series[name] = metric.DataPoints
+	}
+
+	timestamps, values := util.AlignDataPoints(series)
+	if len(timestamps) == 0 {
+		check.AddResultf(nagiosplugin.UNKNOWN, "No data points found at the same time for %v", strings.Join(parsed.MetricNames(), ", "))
+		return
+	}
+
+	last := len(timestamps) - 1
+	age := time.Since(timestamps[last])
+	if int(age.Seconds()) > maxAge {
+		check.AddResultf(nagiosplugin.CRITICAL, "Last data point for %v is %v seconds old.", parsed, int(age.Seconds()))
+		return
+	}
+
+	value, err := parsed.Evaluate(values[last])
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "Error evaluating %v. Error: %v", parsed, err)
+		return
+	}
+
+	inputs := make([]string, 0, len(parsed.MetricNames()))
+	for _, name := range parsed.MetricNames() {
+		check.AddPerfDatum(name, "", values[last][name])
+		inputs = append(inputs, fmt.Sprintf("%v=%v", name, values[last][name]))
+	}
+	check.AddPerfDatum("expression", "", value)
+
+	status, err := checkThresholds(value)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	status, note, err := dampStatus(stateKey(host.Id, parsed.String()), status, value)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	message := fmt.Sprintf("%v = %v (%v)%v", parsed, value, strings.Join(inputs, ", "), rateNote(resets, parsed.MetricNames()...))
+	if note != "" {
+		message += " " + note
+	}
+	check.AddResult(status, message)
+}
@@ -0,0 +1,152 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"./model"
+	"./util"
+	"flag"
+	"fmt"
+	"github.com/fractalcat/nagiosplugin"
+	"math"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// MinForecastPoints is the fewest data points a trend is fitted to.
+	MinForecastPoints = 3
+
+	ForecastGranularity = "HOUR"
+
+	// maxProjectedDays is the furthest a projected date can be computed
+	// with time.Duration.
+	maxProjectedDays = math.MaxInt64 / (24 * 60 * 60 * 1e9)
+)
+
+var forecastWindow int
+var forecastFit string
+var forecastLimit string
+
+// doForecastCheck fits a trend to the metric over the window and checks the
+// number of days until the trend reaches the limit against the warning and
+// critical ranges. The limit is approached from the current value, so a limit
+// above it watches growth and a limit below it watches decline.
+func doForecastCheck(check *nagiosplugin.Check, api *util.MMSAPI, host *model.Host) {
+	if metricName == "" || forecastLimit == "" {
+		check.AddResultf(nagiosplugin.UNKNOWN, "The forecast mode requires -m metric and --limit")
+		return
+	}
+
+	limit, err := strconv.ParseFloat(forecastLimit, 64)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "Error parsing limit. Error: %v", err)
+		return
+	}
+
+	fitter, ok := map[string]func([]model.DataPoint) util.Fit{
+		util.FitLinear:   util.LinearFit,
+		util.FitTheilSen: util.TheilSenFit,
+	}[forecastFit]
+	if !ok {
+		check.AddResultf(nagiosplugin.UNKNOWN, "Unknown fit %v", forecastFit)
+		return
+	}
+
+	if err := validateMetric(api, groupId, host.Id, metricName, dbName, partition); err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	query := util.MetricQuery{Granularity: granularityOr(ForecastGranularity), Period: util.PeriodOfDays(forecastWindow)}
+	metric, err := getMetricHistory(api, groupId, host.Id, metricName, dbName, partition, query)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	if len(metric.DataPoints) < MinForecastPoints {
+		check.AddResultf(nagiosplugin.UNKNOWN, "Only %v data points found for %v, at least %v are needed for a forecast",
+			len(metric.DataPoints), metricName, MinForecastPoints)
+		return
+	}
+
+	fit := fitter(metric.DataPoints)
+	current := metric.DataPoints[len(metric.DataPoints)-1].Value
+	now := time.Now()
+	daysLeft := daysUntil(fit, current, limit, now)
+
+	check.AddPerfDatum(metricName, "", current)
+	if !math.IsInf(daysLeft, 1) {
+		check.AddPerfDatum("days_to_limit", "", daysLeft)
+	}
+	check.AddPerfDatum("slope_per_day", "", fit.Slope)
+	check.AddPerfDatum("r2", "", fit.R2)
+
+	status, err := checkThresholds(daysLeft)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	fitInfo := fmt.Sprintf("%v per day, R² %.2f over %v days", fit.Slope, fit.R2, forecastWindow)
+	if math.IsInf(daysLeft, 1) {
+		check.AddResultf(status, "%v is not projected to reach %v (%v)", metricName, limit, fitInfo)
+		return
+	}
+
+	// A date further away than time.Duration can span would overflow, and
+	// is of no interest anyway.
+	if daysLeft > maxProjectedDays {
+		check.AddResultf(status, "%v is projected to reach %v in %.0f days (%v)", metricName, limit, daysLeft, fitInfo)
+		return
+	}
+
+	projected := now.Add(time.Duration(daysLeft * float64(24*time.Hour)))
+	check.AddResultf(status, "%v is projected to reach %v in %.1f days on %v (%v)",
+		metricName, limit, daysLeft, projected.Format("2006-01-02"), fitInfo)
+}
+
+// daysUntil returns the days from now until the trend reaches the limit, or
+// +Inf if the trend is moving away from it.
+func daysUntil(fit util.Fit, current float64, limit float64, now time.Time) float64 {
+	if current == limit {
+		return 0
+	}
+
+	if math.IsNaN(fit.Slope) || (limit > current && fit.Slope <= 0) || (limit < current && fit.Slope >= 0) {
+		return math.Inf(1)
+	}
+
+	daysLeft, ok := fit.DaysUntil(limit, now)
+	if !ok {
+		return math.Inf(1)
+	}
+
+	return math.Max(0, daysLeft)
+}
+
+const (
+	forecastWindowDefault = 14
+	forecastWindowUsage   = "days of history the trend is fitted to"
+	forecastFitDefault    = util.FitLinear
+	forecastFitUsage      = "how the trend is fitted: linear or theilsen"
+	forecastLimitUsage    = "the value the forecast counts the days until, such as the size of the disk"
+)
+
+func setupForecastFlags() {
+	flag.IntVar(&forecastWindow, "window", forecastWindowDefault, forecastWindowUsage)
+	flag.StringVar(&forecastFit, "fit", forecastFitDefault, forecastFitUsage)
+	flag.StringVar(&forecastLimit, "limit", "", forecastLimitUsage)
+}
+
+func forecastUsage() {
+	fmt.Fprintf(os.Stdout, "\n     forecast mode: -w and -c are ranges of the days until the trend reaches the limit, e.g. -w 30: -c 7:\n")
+	fmt.Fprintf(os.Stdout, "     data points have a granularity of %v unless -G is given\n", ForecastGranularity)
+	fmt.Fprintf(os.Stdout, "     --window (default: %v) %v\n", forecastWindowDefault, forecastWindowUsage)
+	fmt.Fprintf(os.Stdout, "     --fit (default: %v) %v\n", forecastFitDefault, forecastFitUsage)
+	fmt.Fprintf(os.Stdout, "     --limit %v\n", forecastLimitUsage)
+}
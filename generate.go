@@ -0,0 +1,319 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"./model"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"text/template"
+)
+
+// service is a single check that is generated for every host of a role.
+// A service without a metric checks the age of the host's last ping.
+type service struct {
+	Description string `json:"description"`
+	Metric      string `json:"metric"`
+	DBName      string `json:"dbname"`
+	Partition   string `json:"partition"`
+	Warning     string `json:"warning"`
+	Critical    string `json:"critical"`
+}
+
+// defaultServices are the services generated for each host role when no
+// services file is given.
+var defaultServices = map[string][]service{
+	model.RolePrimary: {
+		{Description: "Last Ping", Warning: "180", Critical: "300"},
+		{Description: "Connections", Metric: "CONNECTIONS", Warning: "~:", Critical: "~:"},
+		{Description: "Inserts/Sec", Metric: "OPCOUNTERS_INSERT", Warning: "~:", Critical: "~:"},
+		{Description: "Queries/Sec", Metric: "OPCOUNTERS_QUERY", Warning: "~:", Critical: "~:"},
+		{Description: "Updates/Sec", Metric: "OPCOUNTERS_UPDATE", Warning: "~:", Critical: "~:"},
+		{Description: "Deletes/Sec", Metric: "OPCOUNTERS_DELETE", Warning: "~:", Critical: "~:"},
+		{Description: "Queued Readers", Metric: "GLOBAL_LOCK_CURRENT_QUEUE_READERS", Warning: "10", Critical: "50"},
+		{Description: "Queued Writers", Metric: "GLOBAL_LOCK_CURRENT_QUEUE_WRITERS", Warning: "10", Critical: "50"},
+		{Description: "Replication Headroom", Metric: "OPLOG_MASTER_LAG_TIME_DIFF", Warning: "86400:", Critical: "3600:"},
+	},
+	model.RoleSecondary: {
+		{Description: "Last Ping", Warning: "180", Critical: "300"},
+		{Description: "Connections", Metric: "CONNECTIONS", Warning: "~:", Critical: "~:"},
+		{Description: "Replication Lag", Metric: "OPLOG_SLAVE_LAG_MASTER_TIME", Warning: "60", Critical: "300"},
+		{Description: "Replicated Inserts/Sec", Metric: "OPCOUNTERS_REPL_INSERT", Warning: "~:", Critical: "~:"},
+	},
+	model.RoleMongos: {
+		{Description: "Last Ping", Warning: "180", Critical: "300"},
+		{Description: "Connections", Metric: "CONNECTIONS", Warning: "~:", Critical: "~:"},
+		{Description: "Requests/Sec", Metric: "NETWORK_NUM_REQUESTS", Warning: "~:", Critical: "~:"},
+	},
+}
+
+// defaultRoleServices are generated for roles that have no services of their
+// own, such as arbiters and config servers.
+var defaultRoleServices = []service{
+	{Description: "Last Ping", Warning: "180", Critical: "300"},
+}
+
+// CheckCommand returns the Nagios check_command line for the service.
+func (s service) CheckCommand() string {
+	if s.Metric == "" {
+		return fmt.Sprintf("check_mongodb_mms_ping!%v!%v", s.Warning, s.Critical)
+	}
+
+	if s.DBName != "" {
+		return fmt.Sprintf("check_mongodb_mms_db_metric!%v!%v!%v!%v", s.Metric, s.DBName, s.Warning, s.Critical)
+	}
+
+	if s.Partition != "" {
+		return fmt.Sprintf("check_mongodb_mms_disk_metric!%v!%v!%v!%v", s.Metric, s.Partition, s.Warning, s.Critical)
+	}
+
+	return fmt.Sprintf("check_mongodb_mms_metric!%v!%v!%v", s.Metric, s.Warning, s.Critical)
+}
+
+// Arguments returns the check_mongodb_mms flags that run the service's check.
+func (s service) Arguments() string {
+	args := ""
+	if s.Metric != "" {
+		args += fmt.Sprintf("-m %v ", s.Metric)
+	}
+
+	if s.DBName != "" {
+		args += fmt.Sprintf("-d %v ", s.DBName)
+	}
+
+	if s.Partition != "" {
+		args += fmt.Sprintf("-p %v ", s.Partition)
+	}
+
+	return args + fmt.Sprintf("-w %v -c %v", s.Warning, s.Critical)
+}
+
+type generateHost struct {
+	model.Host
+	Role     string
+	Services []service
+}
+
+type generateData struct {
+	GroupId string
+	Server  string
+	Timeout int
+	Plugin  string
+	Hosts   []generateHost
+}
+
+var generateGroupId string
+var generateServer string
+var generateTimeout int
+var generatePlugin string
+var generateTemplate string
+var generateServices string
+var generateOutput string
+var generateFormat string
+
+func runGenerate(args []string) {
+	flags := setupGenerateFlags(args)
+	if generateGroupId == "" {
+		flags.Usage()
+		os.Exit(2)
+		return
+	}
+
+	if _, ok := defaultTemplates[generateFormat]; !ok {
+		exitWithError(errors.New(fmt.Sprintf("Unknown format %v", generateFormat)))
+	}
+
+	services := defaultServices
+	if generateServices != "" {
+		var err error
+		if services, err = loadServices(generateServices); err != nil {
+			exitWithError(err)
+		}
+	}
+
+	tmpl, err := loadTemplate(generateFormat, generateTemplate)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	api, err := newAPI(generateServer, generateTimeout)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	hosts, err := api.GetAllHosts(generateGroupId)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	data := generateData{
+		GroupId: generateGroupId,
+		Server:  generateServer,
+		Timeout: generateTimeout,
+		Plugin:  generatePlugin,
+		Hosts:   buildGenerateHosts(hosts, services),
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&out, generateFormat, data); err != nil {
+		exitWithError(errors.New(fmt.Sprintf("Failed to render template. Error: %v", err)))
+	}
+
+	if generateOutput == "" {
+		os.Stdout.Write(out.Bytes())
+		return
+	}
+
+	if err := ioutil.WriteFile(generateOutput, out.Bytes(), 0644); err != nil {
+		exitWithError(errors.New(fmt.Sprintf("Failed to write %v. Error: %v", generateOutput, err)))
+	}
+}
+
+// buildGenerateHosts attaches the services for each host's role and sorts
+// hosts and services so that the generated output is stable between runs.
+func buildGenerateHosts(hosts []model.Host, services map[string][]service) []generateHost {
+	ret := make([]generateHost, 0, len(hosts))
+	for _, host := range hosts {
+		role := host.Role()
+		roleServices, ok := services[role]
+		if !ok {
+			roleServices = defaultRoleServices
+		}
+
+		sorted := make([]service, len(roleServices))
+		copy(sorted, roleServices)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].Description < sorted[j].Description
+		})
+
+		ret = append(ret, generateHost{Host: host, Role: role, Services: sorted})
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].Hostname != ret[j].Hostname {
+			return ret[i].Hostname < ret[j].Hostname
+		}
+		return ret[i].Port < ret[j].Port
+	})
+
+	return ret
+}
+
+// loadTemplate parses the default templates of the format followed by the
+// user's template file, so a user template only needs to redefine the parts
+// it changes.
+func loadTemplate(format string, templateFile string) (*template.Template, error) {
+	funcs := template.FuncMap{
+		"serviceOf": func(data generateData, host generateHost, s service) map[string]interface{} {
+			return map[string]interface{}{"Data": data, "Host": host, "Service": s}
+		},
+		"quote": strconv.Quote,
+	}
+
+	tmpl, err := template.New("defaults").Funcs(funcs).Parse(defaultTemplates[format])
+	if err != nil {
+		return nil, err
+	}
+
+	if templateFile == "" {
+		return tmpl, nil
+	}
+
+	buffer, err := ioutil.ReadFile(templateFile)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Failed to read template %v. Error: %v", templateFile, err))
+	}
+
+	if _, err := tmpl.New(templateFile).Parse(string(buffer)); err != nil {
+		return nil, errors.New(fmt.Sprintf("Failed to parse template %v. Error: %v", templateFile, err))
+	}
+
+	return tmpl, nil
+}
+
+// loadServices reads a JSON file mapping a host role to the services that
+// should be generated for it.
+func loadServices(servicesFile string) (map[string][]service, error) {
+	buffer, err := ioutil.ReadFile(servicesFile)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Failed to read services %v. Error: %v", servicesFile, err))
+	}
+
+	services := make(map[string][]service)
+	if err := json.Unmarshal(buffer, &services); err != nil {
+		return nil, errors.New(fmt.Sprintf("Failed to parse services %v. Error: %v", servicesFile, err))
+	}
+
+	return services, nil
+}
+
+func setupGenerateFlags(args []string) *flag.FlagSet {
+	const (
+		groupIdDefault  = ""
+		groupIdUsage    = "The MMS/Ops Manager group ID to generate configuration for"
+		serverDefault   = "https://mms.mongodb.com"
+		serverUsage     = "hostname and port of the MMS/Ops Manager service"
+		timeoutDefault  = 10
+		timeoutUsage    = "connection timeout connecting MMS/Ops Manager service"
+		pluginDefault   = "/usr/local/bin/check_mongodb_mms"
+		pluginUsage     = "path to check_mongodb_mms on the Nagios server"
+		templateDefault = ""
+		templateUsage   = "file of templates that replace the built in templates of the format"
+		servicesDefault = ""
+		servicesUsage   = "JSON file mapping host roles (primary, secondary, mongos, ...) to services"
+		outputDefault   = ""
+		outputUsage     = "file to write the configuration to"
+		formatDefault   = "nagios"
+		formatUsage     = "configuration format to generate: nagios, icinga2 or checkmk"
+	)
+
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+
+	flags.StringVar(&generateGroupId, "groupid", groupIdDefault, groupIdUsage)
+	flags.StringVar(&generateGroupId, "g", groupIdDefault, groupIdUsage)
+
+	flags.StringVar(&generateServer, "server", serverDefault, serverUsage)
+	flags.StringVar(&generateServer, "s", serverDefault, serverUsage)
+
+	flags.IntVar(&generateTimeout, "timeout", timeoutDefault, timeoutUsage)
+	flags.IntVar(&generateTimeout, "t", timeoutDefault, timeoutUsage)
+
+	flags.StringVar(&generatePlugin, "plugin", pluginDefault, pluginUsage)
+	flags.StringVar(&generatePlugin, "p", pluginDefault, pluginUsage)
+
+	flags.StringVar(&generateTemplate, "template", templateDefault, templateUsage)
+	flags.StringVar(&generateTemplate, "T", templateDefault, templateUsage)
+
+	flags.StringVar(&generateServices, "services", servicesDefault, servicesUsage)
+	flags.StringVar(&generateServices, "S", servicesDefault, servicesUsage)
+
+	flags.StringVar(&generateOutput, "output", outputDefault, outputUsage)
+	flags.StringVar(&generateOutput, "o", outputDefault, outputUsage)
+
+	flags.StringVar(&generateFormat, "format", formatDefault, formatUsage)
+	flags.StringVar(&generateFormat, "f", formatDefault, formatUsage)
+
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stdout, "Usage: check_mongodb_mms generate -g groupid [-s server] [-t timeout] [-p plugin] [-T template] [-S services] [-f format] [-o output]\n")
+		fmt.Fprintf(os.Stdout, "     -g, --groupid  %v\n", groupIdUsage)
+		fmt.Fprintf(os.Stdout, "     -s, --server (default: %v) %v\n", serverDefault, serverUsage)
+		fmt.Fprintf(os.Stdout, "     -t, --timeout (default: %v) %v\n", timeoutDefault, timeoutUsage)
+		fmt.Fprintf(os.Stdout, "     -p, --plugin (default: %v) %v\n", pluginDefault, pluginUsage)
+		fmt.Fprintf(os.Stdout, "     -T, --template %v\n", templateUsage)
+		fmt.Fprintf(os.Stdout, "     -S, --services %v\n", servicesUsage)
+		fmt.Fprintf(os.Stdout, "     -f, --format (default: %v) %v\n", formatDefault, formatUsage)
+		fmt.Fprintf(os.Stdout, "     -o, --output (default: stdout) %v\n", outputUsage)
+	}
+	flags.Parse(args)
+
+	return flags
+}
@@ -0,0 +1,112 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+// defaultTemplates maps each configuration format to its built in templates.
+// Every format defines a template named after itself that renders the whole
+// configuration from a generateData.
+var defaultTemplates = map[string]string{
+	"nagios":  defaultNagiosTemplate,
+	"icinga2": defaultIcinga2Template,
+	"checkmk": defaultCheckmkTemplate,
+}
+
+// The Nagios service template is executed with a map holding the Data, Host
+// and Service being rendered.
+const defaultNagiosTemplate = `{{define "command"}}define command {
+    command_name  check_mongodb_mms_ping
+    command_line  {{.Plugin}} -s {{.Server}} -t {{.Timeout}} -g $_HOSTGROUPID$ -H $HOSTADDRESS$:$_HOSTPORT$ -w $ARG1$ -c $ARG2$
+}
+
+define command {
+    command_name  check_mongodb_mms_metric
+    command_line  {{.Plugin}} -s {{.Server}} -t {{.Timeout}} -g $_HOSTGROUPID$ -H $HOSTADDRESS$:$_HOSTPORT$ -m $ARG1$ -w $ARG2$ -c $ARG3$
+}
+
+define command {
+    command_name  check_mongodb_mms_db_metric
+    command_line  {{.Plugin}} -s {{.Server}} -t {{.Timeout}} -g $_HOSTGROUPID$ -H $HOSTADDRESS$:$_HOSTPORT$ -m $ARG1$ -d $ARG2$ -w $ARG3$ -c $ARG4$
+}
+
+define command {
+    command_name  check_mongodb_mms_disk_metric
+    command_line  {{.Plugin}} -s {{.Server}} -t {{.Timeout}} -g $_HOSTGROUPID$ -H $HOSTADDRESS$:$_HOSTPORT$ -m $ARG1$ -p $ARG2$ -w $ARG3$ -c $ARG4$
+}
+{{end}}{{define "host"}}
+define host {
+    use                     generic-host
+    host_name               {{.Name}}
+    alias                   {{.Name}}
+    address                 {{.Hostname}}
+    _PORT                   {{.Port}}
+    _GROUPID                {{.GroupId}}
+}
+{{end}}{{define "service"}}
+define service {
+    use                     generic-service
+    host_name               {{.Host.Name}}
+    service_description     {{.Service.Description}}
+    check_command           {{.Service.CheckCommand}}
+}
+{{end}}{{define "nagios"}}# Generated by check_mongodb_mms generate for group {{.GroupId}}.
+{{template "command" .}}{{range $host := .Hosts}}{{template "host" $host}}{{range $host.Services}}{{template "service" (serviceOf $ $host .)}}{{end}}{{end}}{{end}}`
+
+// The Icinga 2 host template carries its services as a dictionary in a custom
+// variable, which a single apply for rule turns into services.
+const defaultIcinga2Template = `{{define "command"}}object CheckCommand "mongodb_mms" {
+  command = [ {{quote .Plugin}} ]
+
+  arguments = {
+    "-s" = {{quote .Server}}
+    "-t" = "{{.Timeout}}"
+    "-g" = "$mongodb_mms_groupid$"
+    "-H" = "$address$:$mongodb_mms_port$"
+    "-m" = "$mongodb_mms_metric$"
+    "-d" = "$mongodb_mms_dbname$"
+    "-p" = "$mongodb_mms_partition$"
+    "-w" = "$mongodb_mms_warning$"
+    "-c" = "$mongodb_mms_critical$"
+  }
+}
+
+apply Service for (description => config in host.vars.mongodb_mms_services) {
+  import "generic-service"
+
+  check_command = "mongodb_mms"
+  vars += config
+
+  assign where host.vars.mongodb_mms_groupid
+}
+{{end}}{{define "host"}}
+object Host {{quote .Name}} {
+  import "generic-host"
+
+  address = {{quote .Hostname}}
+  vars.mongodb_mms_groupid = {{quote .GroupId}}
+  vars.mongodb_mms_port = {{.Port}}
+  vars.mongodb_mms_role = {{quote .Role}}
+{{range .Services}}{{template "service" .}}{{end}}}
+{{end}}{{define "service"}}
+  vars.mongodb_mms_services[{{quote .Description}}] = {
+{{if .Metric}}    mongodb_mms_metric = {{quote .Metric}}
+{{end}}{{if .DBName}}    mongodb_mms_dbname = {{quote .DBName}}
+{{end}}{{if .Partition}}    mongodb_mms_partition = {{quote .Partition}}
+{{end}}    mongodb_mms_warning = {{quote .Warning}}
+    mongodb_mms_critical = {{quote .Critical}}
+  }
+{{end}}{{define "icinga2"}}// Generated by check_mongodb_mms generate for group {{.GroupId}}.
+{{template "command" .}}{{range .Hosts}}{{template "host" .}}{{end}}{{end}}`
+
+// The Checkmk templates render a rules.mk for the "Classical active and
+// passive Monitoring checks" ruleset. Checkmk hosts are machines, so the port
+// is part of the service description and the hosts must already exist in WATO.
+const defaultCheckmkTemplate = `{{define "service"}}  {'condition': {'host_name': [{{quote .Host.Hostname}}]},
+   'value': {'service_description': {{quote (printf "MongoDB %v %v" .Host.Port .Service.Description)}},
+             'command_line': {{quote (printf "%v -s %v -t %v -g %v -H $HOSTADDRESS$:%v %v" .Data.Plugin .Data.Server .Data.Timeout .Host.GroupId .Host.Port .Service.Arguments)}},
+             'has_perfdata': True}},
+{{end}}{{define "checkmk"}}# Generated by check_mongodb_mms generate for group {{.GroupId}}.
+custom_checks = [
+{{range $host := .Hosts}}{{range $host.Services}}{{template "service" (serviceOf $ $host .)}}{{end}}{{end}}] + custom_checks
+{{end}}`
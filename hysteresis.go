@@ -0,0 +1,171 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"./util"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/fractalcat/nagiosplugin"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	StateDir = ".mongodb_mms_state"
+)
+
+var recoveryWarning string
+var recoveryCritical string
+var minDuration int
+var stateDir string
+
+// thresholdState is what a check remembers between runs: the state it last
+// reported, and the worse state the value has been in since PendingSince
+// without being reported yet because of --min-duration.
+type thresholdState struct {
+	Status       nagiosplugin.Status `json:"status"`
+	Pending      nagiosplugin.Status `json:"pending"`
+	PendingSince time.Time           `json:"pendingSince"`
+}
+
+// hysteresisEnabled returns true if any of the flags that need the previous
+// state of the check were given.
+func hysteresisEnabled() bool {
+	return recoveryWarning != "" || recoveryCritical != "" || minDuration > 0
+}
+
+// stateKey identifies the series a check remembers its state for.
+func stateKey(hostId string, checked string) string {
+	return strings.Join([]string{mode, groupId, hostId, checked, dbName, partition}, "/")
+}
+
+// dampStatus stops a value hovering around a threshold from flapping. It
+// returns the status to report and a note explaining why it differs from the
+// status of the value, remembering the state of the check under the key for
+// the next run. If there is no previous state, or it can't be read, the check
+// was OK before, so a spike on the first run is held back too.
+func dampStatus(key string, status nagiosplugin.Status, value float64) (nagiosplugin.Status, string, error) {
+	if !hysteresisEnabled() || status == nagiosplugin.UNKNOWN {
+		return status, "", nil
+	}
+
+	dir, err := stateDirPath()
+	if err != nil {
+		return nagiosplugin.UNKNOWN, "", err
+	}
+
+	store := util.NewStateStore(dir)
+	var previous thresholdState
+	if !store.Load(key, &previous) {
+		previous = thresholdState{Status: nagiosplugin.OK}
+	}
+
+	next, reported, note, err := nextThresholdState(previous, status, value, time.Now())
+	if err != nil {
+		return nagiosplugin.UNKNOWN, "", err
+	}
+
+	if err := store.Save(key, next); err != nil {
+		note = strings.TrimSpace(fmt.Sprintf("%v (%v)", note, err))
+	}
+
+	return reported, note, nil
+}
+
+// nextThresholdState returns the state to remember after a run at now that
+// found the value with the status, the status to report and a note. A check
+// that was WARNING or CRITICAL only recovers once the value is outside the
+// recovery range, and with --min-duration a worse state is only reported once
+// the value has been worse than the reported state for that many seconds.
+func nextThresholdState(previous thresholdState, status nagiosplugin.Status, value float64, now time.Time) (thresholdState, nagiosplugin.Status, string, error) {
+	reported, note, err := holdUntilRecovered(previous.Status, status, value)
+	if err != nil {
+		return thresholdState{}, nagiosplugin.UNKNOWN, "", err
+	}
+
+	next := thresholdState{Status: reported}
+	if minDuration > 0 && statusSeverity(reported) > statusSeverity(previous.Status) {
+		// The value has been worse than the reported state since the
+		// pending one started, even if it got worse again since.
+		next.Pending = reported
+		next.PendingSince = previous.PendingSince
+		if statusSeverity(previous.Pending) <= statusSeverity(previous.Status) || previous.PendingSince.IsZero() {
+			next.PendingSince = now
+		}
+
+		pending := now.Sub(next.PendingSince)
+		if pending < time.Duration(minDuration)*time.Second {
+			next.Status = previous.Status
+			note = fmt.Sprintf("(%v for %v of %v seconds)", reported, int(pending.Seconds()), minDuration)
+			reported = previous.Status
+		}
+	}
+
+	return next, reported, note, nil
+}
+
+// holdUntilRecovered keeps the previous CRITICAL or WARNING state while the
+// value is still in the recovery range for it.
+func holdUntilRecovered(previous nagiosplugin.Status, status nagiosplugin.Status, value float64) (nagiosplugin.Status, string, error) {
+	if previous == nagiosplugin.CRITICAL && statusSeverity(status) < statusSeverity(nagiosplugin.CRITICAL) && recoveryCritical != "" {
+		critRange, err := nagiosplugin.ParseRange(recoveryCritical)
+		if err != nil {
+			return nagiosplugin.UNKNOWN, "", errors.New(fmt.Sprintf("Error parsing critical recovery range. Error: %v", err))
+		}
+
+		if critRange.Check(value) {
+			return nagiosplugin.CRITICAL, "(CRITICAL until the value is outside " + recoveryCritical + ")", nil
+		}
+	}
+
+	if previous != nagiosplugin.OK && previous != nagiosplugin.UNKNOWN && status == nagiosplugin.OK && recoveryWarning != "" {
+		warnRange, err := nagiosplugin.ParseRange(recoveryWarning)
+		if err != nil {
+			return nagiosplugin.UNKNOWN, "", errors.New(fmt.Sprintf("Error parsing warning recovery range. Error: %v", err))
+		}
+
+		if warnRange.Check(value) {
+			return nagiosplugin.WARNING, "(WARNING until the value is outside " + recoveryWarning + ")", nil
+		}
+	}
+
+	return status, "", nil
+}
+
+// stateDirPath returns the directory given with --state-dir, or the default
+// one in the user's home directory.
+func stateDirPath() (string, error) {
+	if stateDir != "" {
+		return stateDir, nil
+	}
+
+	return util.HomePath(StateDir)
+}
+
+const (
+	recoveryWarningUsage  = "range the value must leave before a WARNING or CRITICAL check recovers to OK, e.g. -w 80 --recovery-warning 70"
+	recoveryCriticalUsage = "range the value must leave before a CRITICAL check recovers to WARNING or OK, e.g. -c 90 --recovery-critical 85"
+	minDurationDefault    = 0
+	minDurationUsage      = "seconds the value must be in a worse state before it is reported"
+	stateDirUsage         = "directory the state of checks is kept in between runs"
+)
+
+func setupHysteresisFlags() {
+	flag.StringVar(&recoveryWarning, "recovery-warning", "", recoveryWarningUsage)
+	flag.StringVar(&recoveryCritical, "recovery-critical", "", recoveryCriticalUsage)
+	flag.IntVar(&minDuration, "min-duration", minDurationDefault, minDurationUsage)
+	flag.StringVar(&stateDir, "state-dir", "", stateDirUsage)
+}
+
+func hysteresisUsage() {
+	fmt.Fprintf(os.Stdout, "\n     flap suppression of -m and -e checks, which remember their state between runs:\n")
+	fmt.Fprintf(os.Stdout, "     --recovery-warning %v\n", recoveryWarningUsage)
+	fmt.Fprintf(os.Stdout, "     --recovery-critical %v\n", recoveryCriticalUsage)
+	fmt.Fprintf(os.Stdout, "     --min-duration (default: %v) %v\n", minDurationDefault, minDurationUsage)
+	fmt.Fprintf(os.Stdout, "     --state-dir (default: ~/%v) %v\n", StateDir, stateDirUsage)
+}
@@ -0,0 +1,121 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"./util"
+	"github.com/fractalcat/nagiosplugin"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+// withHysteresis sets the flap suppression flags for a test, and returns a
+// function restoring them.
+func withHysteresis(warning string, critical string, duration int) func() {
+	savedWarning, savedCritical, savedDuration := recoveryWarning, recoveryCritical, minDuration
+	recoveryWarning, recoveryCritical, minDuration = warning, critical, duration
+	return func() {
+		recoveryWarning, recoveryCritical, minDuration = savedWarning, savedCritical, savedDuration
+	}
+}
+
+func TestNextThresholdState(t *testing.T) {
+	now := time.Date(2015, 6, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(seconds int) time.Time {
+		return now.Add(-time.Duration(seconds) * time.Second)
+	}
+
+	tests := []struct {
+		name             string
+		recoveryWarning  string
+		recoveryCritical string
+		minDuration      int
+		previous         thresholdState
+		status           nagiosplugin.Status
+		value            float64
+		reported         nagiosplugin.Status
+		next             thresholdState
+		note             string
+	}{
+		{"no state", "", "", 0, thresholdState{}, nagiosplugin.WARNING, 82,
+			nagiosplugin.WARNING, thresholdState{Status: nagiosplugin.WARNING}, ""},
+		{"warning held in the recovery range", "70", "", 0, thresholdState{Status: nagiosplugin.WARNING}, nagiosplugin.OK, 75,
+			nagiosplugin.WARNING, thresholdState{Status: nagiosplugin.WARNING}, "(WARNING until the value is outside 70)"},
+		{"warning recovers below the recovery range", "70", "", 0, thresholdState{Status: nagiosplugin.WARNING}, nagiosplugin.OK, 65,
+			nagiosplugin.OK, thresholdState{Status: nagiosplugin.OK}, ""},
+		{"critical held in the recovery range", "70", "85", 0, thresholdState{Status: nagiosplugin.CRITICAL}, nagiosplugin.WARNING, 87,
+			nagiosplugin.CRITICAL, thresholdState{Status: nagiosplugin.CRITICAL}, "(CRITICAL until the value is outside 85)"},
+		{"critical recovers to warning", "70", "85", 0, thresholdState{Status: nagiosplugin.CRITICAL}, nagiosplugin.WARNING, 82,
+			nagiosplugin.WARNING, thresholdState{Status: nagiosplugin.WARNING}, ""},
+		{"critical recovers to the warning recovery range", "70", "85", 0, thresholdState{Status: nagiosplugin.CRITICAL}, nagiosplugin.OK, 75,
+			nagiosplugin.WARNING, thresholdState{Status: nagiosplugin.WARNING}, "(WARNING until the value is outside 70)"},
+		{"spike held back", "", "", 60, thresholdState{}, nagiosplugin.CRITICAL, 95,
+			nagiosplugin.OK, thresholdState{Pending: nagiosplugin.CRITICAL, PendingSince: now}, "(CRITICAL for 0 of 60 seconds)"},
+		{"spike still held back", "", "", 60, thresholdState{Pending: nagiosplugin.CRITICAL, PendingSince: ago(30)}, nagiosplugin.CRITICAL, 95,
+			nagiosplugin.OK, thresholdState{Pending: nagiosplugin.CRITICAL, PendingSince: ago(30)}, "(CRITICAL for 30 of 60 seconds)"},
+		{"reported after the duration", "", "", 60, thresholdState{Pending: nagiosplugin.CRITICAL, PendingSince: ago(61)}, nagiosplugin.CRITICAL, 95,
+			nagiosplugin.CRITICAL, thresholdState{Status: nagiosplugin.CRITICAL, Pending: nagiosplugin.CRITICAL, PendingSince: ago(61)}, ""},
+		{"spike over", "", "", 60, thresholdState{Pending: nagiosplugin.CRITICAL, PendingSince: ago(30)}, nagiosplugin.OK, 50,
+			nagiosplugin.OK, thresholdState{}, ""},
+		{"warning to critical keeps the timer", "", "", 60, thresholdState{Pending: nagiosplugin.WARNING, PendingSince: ago(55)}, nagiosplugin.CRITICAL, 95,
+			nagiosplugin.OK, thresholdState{Pending: nagiosplugin.CRITICAL, PendingSince: ago(55)}, "(CRITICAL for 55 of 60 seconds)"},
+		{"warning to critical reported on time", "", "", 60, thresholdState{Pending: nagiosplugin.WARNING, PendingSince: ago(65)}, nagiosplugin.CRITICAL, 95,
+			nagiosplugin.CRITICAL, thresholdState{Status: nagiosplugin.CRITICAL, Pending: nagiosplugin.CRITICAL, PendingSince: ago(65)}, ""},
+		{"critical after a reported warning starts a timer", "", "", 60,
+			thresholdState{Status: nagiosplugin.WARNING, Pending: nagiosplugin.WARNING, PendingSince: ago(120)}, nagiosplugin.CRITICAL, 95,
+			nagiosplugin.WARNING, thresholdState{Status: nagiosplugin.WARNING, Pending: nagiosplugin.CRITICAL, PendingSince: now}, "(CRITICAL for 0 of 60 seconds)"},
+		{"recovery is not delayed", "", "", 60, thresholdState{Status: nagiosplugin.CRITICAL}, nagiosplugin.OK, 50,
+			nagiosplugin.OK, thresholdState{}, ""},
+	}
+
+	for _, test := range tests {
+		restore := withHysteresis(test.recoveryWarning, test.recoveryCritical, test.minDuration)
+		next, reported, note, err := nextThresholdState(test.previous, test.status, test.value, now)
+		restore()
+
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+		if reported != test.reported || note != test.note {
+			t.Errorf("%v: reported %v %q, expected %v %q", test.name, reported, note, test.reported, test.note)
+		}
+		if next.Status != test.next.Status || next.Pending != test.next.Pending || !next.PendingSince.Equal(test.next.PendingSince) {
+			t.Errorf("%v: next state %+v, expected %+v", test.name, next, test.next)
+		}
+	}
+}
+
+func TestDampStatusWithoutState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hysteresis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(saved string) { stateDir = saved }(stateDir)
+	stateDir = dir
+	defer withHysteresis("", "", 60)()
+
+	// A document that isn't a state is as good as none.
+	store := util.NewStateStore(dir)
+	if err := store.Save("corrupt", "not a state"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"missing", "corrupt"} {
+		status, note, err := dampStatus(key, nagiosplugin.CRITICAL, 95)
+		if err != nil || status != nagiosplugin.OK || note != "(CRITICAL for 0 of 60 seconds)" {
+			t.Errorf("%v state: %v %q (%v), expected the spike to be held back", key, status, note, err)
+		}
+
+		var saved thresholdState
+		if !store.Load(key, &saved) || saved.Pending != nagiosplugin.CRITICAL {
+			t.Errorf("%v state: saved %+v, expected a pending CRITICAL", key, saved)
+		}
+	}
+}
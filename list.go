@@ -0,0 +1,261 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"./util"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// listing is the result of a list subcommand. The rows are printed as a
+// table, the value as JSON.
+type listing struct {
+	headers []string
+	rows    [][]string
+	value   interface{}
+}
+
+type lister struct {
+	needsGroup bool
+	needsHost  bool
+	list       func(api *util.MMSAPI) (*listing, error)
+}
+
+var listers = map[string]lister{
+	"groups":     {false, false, listGroups},
+	"hosts":      {true, false, listHosts},
+	"metrics":    {true, true, listMetrics},
+	"databases":  {true, true, listDatabases},
+	"partitions": {true, true, listPartitions},
+}
+
+var listGroupId string
+var listHostname string
+var listJSON bool
+var listServer string
+var listTimeout int
+
+func runList(args []string) {
+	if len(args) == 0 {
+		listUsage()
+		os.Exit(2)
+		return
+	}
+
+	l, ok := listers[args[0]]
+	if !ok {
+		listUsage()
+		os.Exit(2)
+		return
+	}
+
+	setupListFlags(args[1:])
+	if (l.needsGroup && listGroupId == "") || (l.needsHost && listHostname == "") {
+		listUsage()
+		os.Exit(2)
+		return
+	}
+
+	api, err := newAPI(listServer, listTimeout)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	result, err := l.list(api)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	printListing(result, listJSON)
+}
+
+// printListing prints the listing as a table, or as JSON if asJSON is true.
+func printListing(result *listing, asJSON bool) {
+	if asJSON {
+		output, err := json.MarshalIndent(result.value, "", "  ")
+		if err != nil {
+			exitWithError(err)
+		}
+
+		fmt.Fprintf(os.Stdout, "%s\n", output)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(result.headers, "\t"))
+	for _, row := range result.rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+}
+
+func listGroups(api *util.MMSAPI) (*listing, error) {
+	groups, err := api.GetGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+
+	result := &listing{headers: []string{"ID", "NAME", "ACTIVE AGENTS", "REPLICA SETS", "SHARDS"}, value: groups}
+	for _, group := range groups {
+		result.rows = append(result.rows, []string{
+			group.Id,
+			group.Name,
+			strconv.Itoa(group.ActiveAgentCount),
+			strconv.Itoa(group.ReplicaSetCount),
+			strconv.Itoa(group.ShardCount),
+		})
+	}
+
+	return result, nil
+}
+
+func listHosts(api *util.MMSAPI) (*listing, error) {
+	hosts, err := api.GetAllHosts(listGroupId)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(hosts, func(i, j int) bool {
+		return hosts[i].Name() < hosts[j].Name()
+	})
+
+	result := &listing{headers: []string{"ID", "HOSTNAME", "TYPE", "ROLE", "REPLICA SET", "LAST PING"}, value: hosts}
+	for _, host := range hosts {
+		result.rows = append(result.rows, []string{
+			host.Id,
+			host.Name(),
+			host.TypeName,
+			host.Role(),
+			host.ReplicaSetName,
+			host.LastPing.Format(time.RFC3339),
+		})
+	}
+
+	return result, nil
+}
+
+func listMetrics(api *util.MMSAPI) (*listing, error) {
+	host, err := api.GetHostByName(listGroupId, listHostname)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics, err := api.GetHostMetrics(listGroupId, host.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(metrics, func(i, j int) bool {
+		return metrics[i].MetricName < metrics[j].MetricName
+	})
+
+	result := &listing{headers: []string{"METRIC", "UNITS"}, value: metrics}
+	for _, metric := range metrics {
+		result.rows = append(result.rows, []string{metric.MetricName, metric.Units})
+	}
+
+	return result, nil
+}
+
+func listDatabases(api *util.MMSAPI) (*listing, error) {
+	host, err := api.GetHostByName(listGroupId, listHostname)
+	if err != nil {
+		return nil, err
+	}
+
+	databases, err := api.GetHostDatabases(listGroupId, host.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(databases, func(i, j int) bool {
+		return databases[i].DatabaseName < databases[j].DatabaseName
+	})
+
+	result := &listing{headers: []string{"DATABASE"}, value: databases}
+	for _, database := range databases {
+		result.rows = append(result.rows, []string{database.DatabaseName})
+	}
+
+	return result, nil
+}
+
+func listPartitions(api *util.MMSAPI) (*listing, error) {
+	host, err := api.GetHostByName(listGroupId, listHostname)
+	if err != nil {
+		return nil, err
+	}
+
+	disks, err := api.GetHostDisks(listGroupId, host.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(disks, func(i, j int) bool {
+		return disks[i].PartitionName < disks[j].PartitionName
+	})
+
+	result := &listing{headers: []string{"PARTITION"}, value: disks}
+	for _, disk := range disks {
+		result.rows = append(result.rows, []string{disk.PartitionName})
+	}
+
+	return result, nil
+}
+
+const (
+	listGroupIdUsage   = "The MMS/Ops Manager group ID to list"
+	listHostnameUsage  = "hostname:port of the mongod/s to list"
+	listJSONUsage      = "print JSON instead of a table"
+	listServerDefault  = "https://mms.mongodb.com"
+	listServerUsage    = "hostname and port of the MMS/Ops Manager service"
+	listTimeoutDefault = 10
+	listTimeoutUsage   = "connection timeout connecting MMS/Ops Manager service"
+)
+
+func setupListFlags(args []string) {
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+
+	flags.StringVar(&listGroupId, "groupid", "", listGroupIdUsage)
+	flags.StringVar(&listGroupId, "g", "", listGroupIdUsage)
+
+	flags.StringVar(&listHostname, "hostname", "", listHostnameUsage)
+	flags.StringVar(&listHostname, "H", "", listHostnameUsage)
+
+	flags.BoolVar(&listJSON, "json", false, listJSONUsage)
+	flags.BoolVar(&listJSON, "j", false, listJSONUsage)
+
+	flags.StringVar(&listServer, "server", listServerDefault, listServerUsage)
+	flags.StringVar(&listServer, "s", listServerDefault, listServerUsage)
+
+	flags.IntVar(&listTimeout, "timeout", listTimeoutDefault, listTimeoutUsage)
+	flags.IntVar(&listTimeout, "t", listTimeoutDefault, listTimeoutUsage)
+
+	flags.Usage = listUsage
+	flags.Parse(args)
+}
+
+func listUsage() {
+	fmt.Fprintf(os.Stdout, "Usage: check_mongodb_mms list groups [-j] [-s server] [-t timeout]\n")
+	fmt.Fprintf(os.Stdout, "       check_mongodb_mms list hosts -g groupid [-j] [-s server] [-t timeout]\n")
+	fmt.Fprintf(os.Stdout, "       check_mongodb_mms list metrics|databases|partitions -g groupid -H hostname [-j] [-s server] [-t timeout]\n")
+	fmt.Fprintf(os.Stdout, "     -g, --groupid  %v\n", listGroupIdUsage)
+	fmt.Fprintf(os.Stdout, "     -H, --hostname %v\n", listHostnameUsage)
+	fmt.Fprintf(os.Stdout, "     -j, --json %v\n", listJSONUsage)
+	fmt.Fprintf(os.Stdout, "     -s, --server (default: %v) %v\n", listServerDefault, listServerUsage)
+	fmt.Fprintf(os.Stdout, "     -t, --timeout (default: %v) %v\n", listTimeoutDefault, listTimeoutUsage)
+}
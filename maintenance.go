@@ -0,0 +1,243 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"./model"
+	"./util"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var maintenanceActions = map[string]func(api *util.MMSAPI) error{
+	"create": createMaintenance,
+	"list":   listMaintenance,
+	"delete": deleteMaintenance,
+}
+
+var maintenanceGroupId string
+var maintenanceStart string
+var maintenanceDuration time.Duration
+var maintenanceAlertTypes string
+var maintenanceDescription string
+var maintenanceId string
+var maintenanceJSON bool
+var maintenanceServer string
+var maintenanceTimeout int
+
+// runMaintenance creates, lists and deletes the maintenance windows of a
+// group, so a Nagios downtime can silence MMS/Ops Manager notifications for
+// the same period.
+func runMaintenance(args []string) {
+	if len(args) == 0 {
+		maintenanceUsage()
+		os.Exit(2)
+		return
+	}
+
+	action, ok := maintenanceActions[args[0]]
+	if !ok {
+		maintenanceUsage()
+		os.Exit(2)
+		return
+	}
+
+	setupMaintenanceFlags(args[1:])
+	if maintenanceGroupId == "" || (args[0] == "delete" && maintenanceId == "" && maintenanceDescription == "") {
+		maintenanceUsage()
+		os.Exit(2)
+		return
+	}
+
+	api, err := newAPI(maintenanceServer, maintenanceTimeout)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	if err := action(api); err != nil {
+		exitWithError(err)
+	}
+}
+
+// createMaintenance creates a window unless one with the same description
+// already covers the period.
+func createMaintenance(api *util.MMSAPI) error {
+	if maintenanceDuration <= 0 {
+		return errors.New(fmt.Sprintf("Duration must be positive, not %v", maintenanceDuration))
+	}
+
+	start := time.Now()
+	if maintenanceStart != "" {
+		var err error
+		if start, err = time.Parse(time.RFC3339, maintenanceStart); err != nil {
+			return errors.New(fmt.Sprintf("Error parsing start time. Error: %v", err))
+		}
+	}
+
+	window := model.MaintenanceWindow{
+		StartDate:      start.UTC().Truncate(time.Second),
+		EndDate:        start.Add(maintenanceDuration).UTC().Truncate(time.Second),
+		AlertTypeNames: strings.Split(maintenanceAlertTypes, ","),
+		Description:    maintenanceDescription,
+	}
+
+	if maintenanceDescription != "" {
+		windows, err := api.GetMaintenanceWindows(maintenanceGroupId)
+		if err != nil {
+			return err
+		}
+
+		for _, existing := range windows {
+			if existing.Description == maintenanceDescription && !existing.StartDate.After(window.StartDate) && !existing.EndDate.Before(window.EndDate) {
+				fmt.Fprintf(os.Stdout, "Maintenance window %v already covers %v to %v\n", existing.Id,
+					window.StartDate.Format(time.RFC3339), window.EndDate.Format(time.RFC3339))
+				return nil
+			}
+		}
+	}
+
+	created, err := api.CreateMaintenanceWindow(maintenanceGroupId, window)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stdout, "Created maintenance window %v from %v to %v\n", created.Id,
+		created.StartDate.Format(time.RFC3339), created.EndDate.Format(time.RFC3339))
+	return nil
+}
+
+func listMaintenance(api *util.MMSAPI) error {
+	windows, err := api.GetMaintenanceWindows(maintenanceGroupId)
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(windows, func(i, j int) bool {
+		return windows[i].StartDate.Before(windows[j].StartDate)
+	})
+
+	now := time.Now()
+	result := &listing{headers: []string{"ID", "START", "END", "ACTIVE", "ALERT TYPES", "DESCRIPTION"}, value: windows}
+	for _, window := range windows {
+		result.rows = append(result.rows, []string{
+			window.Id,
+			window.StartDate.Format(time.RFC3339),
+			window.EndDate.Format(time.RFC3339),
+			strconv.FormatBool(window.IsActive(now)),
+			strings.Join(window.AlertTypeNames, ","),
+			window.Description,
+		})
+	}
+
+	printListing(result, maintenanceJSON)
+	return nil
+}
+
+// deleteMaintenance deletes the window with the ID, or the windows with the
+// description that haven't ended yet. Deleting nothing is not an error, so
+// the hook that ends a downtime can run more than once.
+func deleteMaintenance(api *util.MMSAPI) error {
+	var ids []string
+	if maintenanceId != "" {
+		ids = append(ids, maintenanceId)
+	} else {
+		windows, err := api.GetMaintenanceWindows(maintenanceGroupId)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, window := range windows {
+			if window.Description == maintenanceDescription && window.EndDate.After(now) {
+				ids = append(ids, window.Id)
+			}
+		}
+	}
+
+	if len(ids) == 0 {
+		fmt.Fprintf(os.Stdout, "No maintenance windows to delete\n")
+		return nil
+	}
+
+	for _, id := range ids {
+		if err := api.DeleteMaintenanceWindow(maintenanceGroupId, id); err != nil {
+			return errors.New(fmt.Sprintf("Failed to delete maintenance window %v. Error: %v", id, err))
+		}
+
+		fmt.Fprintf(os.Stdout, "Deleted maintenance window %v\n", id)
+	}
+
+	return nil
+}
+
+const (
+	maintenanceGroupIdUsage    = "The MMS/Ops Manager group ID of the maintenance windows"
+	maintenanceStartUsage      = "start of the window as an RFC 3339 time, such as 2015-06-11T22:00:00Z (default: now)"
+	maintenanceDurationDefault = time.Hour
+	maintenanceDurationUsage   = "length of the window, such as 30m or 4h"
+	maintenanceAlertTypesUsage = "comma separated alert types the window silences"
+	maintenanceDescUsage       = "description of the window, which delete can also select windows by"
+	maintenanceIdUsage         = "ID of the window to delete"
+	maintenanceJSONUsage       = "print JSON instead of a table"
+	maintenanceServerDefault   = "https://mms.mongodb.com"
+	maintenanceServerUsage     = "hostname and port of the MMS/Ops Manager service"
+	maintenanceTimeoutDefault  = 10
+	maintenanceTimeoutUsage    = "connection timeout connecting MMS/Ops Manager service"
+)
+
+func setupMaintenanceFlags(args []string) {
+	flags := flag.NewFlagSet("maintenance", flag.ExitOnError)
+
+	flags.StringVar(&maintenanceGroupId, "groupid", "", maintenanceGroupIdUsage)
+	flags.StringVar(&maintenanceGroupId, "g", "", maintenanceGroupIdUsage)
+
+	flags.StringVar(&maintenanceStart, "start", "", maintenanceStartUsage)
+
+	flags.DurationVar(&maintenanceDuration, "duration", maintenanceDurationDefault, maintenanceDurationUsage)
+	flags.DurationVar(&maintenanceDuration, "D", maintenanceDurationDefault, maintenanceDurationUsage)
+
+	alertTypesDefault := strings.Join(model.AlertTypeNames, ",")
+	flags.StringVar(&maintenanceAlertTypes, "alert-types", alertTypesDefault, maintenanceAlertTypesUsage)
+	flags.StringVar(&maintenanceAlertTypes, "T", alertTypesDefault, maintenanceAlertTypesUsage)
+
+	flags.StringVar(&maintenanceDescription, "description", "", maintenanceDescUsage)
+	flags.StringVar(&maintenanceDescription, "C", "", maintenanceDescUsage)
+
+	flags.StringVar(&maintenanceId, "id", "", maintenanceIdUsage)
+	flags.StringVar(&maintenanceId, "I", "", maintenanceIdUsage)
+
+	flags.BoolVar(&maintenanceJSON, "json", false, maintenanceJSONUsage)
+	flags.BoolVar(&maintenanceJSON, "j", false, maintenanceJSONUsage)
+
+	flags.StringVar(&maintenanceServer, "server", maintenanceServerDefault, maintenanceServerUsage)
+	flags.StringVar(&maintenanceServer, "s", maintenanceServerDefault, maintenanceServerUsage)
+
+	flags.IntVar(&maintenanceTimeout, "timeout", maintenanceTimeoutDefault, maintenanceTimeoutUsage)
+	flags.IntVar(&maintenanceTimeout, "t", maintenanceTimeoutDefault, maintenanceTimeoutUsage)
+
+	flags.Usage = maintenanceUsage
+	flags.Parse(args)
+}
+
+func maintenanceUsage() {
+	fmt.Fprintf(os.Stdout, "Usage: check_mongodb_mms maintenance create -g groupid [--start time] [-D duration] [-T alert_types] [-C description] [-s server] [-t timeout]\n")
+	fmt.Fprintf(os.Stdout, "       check_mongodb_mms maintenance list -g groupid [-j] [-s server] [-t timeout]\n")
+	fmt.Fprintf(os.Stdout, "       check_mongodb_mms maintenance delete -g groupid -I id | -C description [-s server] [-t timeout]\n")
+	fmt.Fprintf(os.Stdout, "     -g, --groupid  %v\n", maintenanceGroupIdUsage)
+	fmt.Fprintf(os.Stdout, "     --start %v\n", maintenanceStartUsage)
+	fmt.Fprintf(os.Stdout, "     -D, --duration (default: %v) %v\n", maintenanceDurationDefault, maintenanceDurationUsage)
+	fmt.Fprintf(os.Stdout, "     -T, --alert-types (default: %v) %v\n", strings.Join(model.AlertTypeNames, ","), maintenanceAlertTypesUsage)
+	fmt.Fprintf(os.Stdout, "     -C, --description %v\n", maintenanceDescUsage)
+	fmt.Fprintf(os.Stdout, "     -I, --id %v\n", maintenanceIdUsage)
+	fmt.Fprintf(os.Stdout, "     -j, --json %v\n", maintenanceJSONUsage)
+	fmt.Fprintf(os.Stdout, "     -s, --server (default: %v) %v\n", maintenanceServerDefault, maintenanceServerUsage)
+	fmt.Fprintf(os.Stdout, "     -t, --timeout (default: %v) %v\n", maintenanceTimeoutDefault, maintenanceTimeoutUsage)
+}
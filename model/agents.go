@@ -0,0 +1,35 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package model
+
+import (
+	"time"
+)
+
+const (
+	AgentMonitoring = "MONITORING"
+	AgentBackup     = "BACKUP"
+	AgentAutomation = "AUTOMATION"
+
+	AgentActive  = "ACTIVE"
+	AgentStandby = "STANDBY"
+)
+
+type Agent struct {
+	TypeName  string    `json:"typeName"`
+	Hostname  string    `json:"hostname"`
+	StateName string    `json:"stateName"`
+	ConfCount int       `json:"confCount"`
+	LastConf  time.Time `json:"lastConf"`
+	PingCount int       `json:"pingCount"`
+	LastPing  time.Time `json:"lastPing"`
+	IsManaged bool      `json:"isManaged"`
+	Tag       string    `json:"tag"`
+	Version   string    `json:"version"`
+}
+
+type AgentsResponse struct {
+	Agents []Agent `json:"results"`
+}
@@ -0,0 +1,70 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package model
+
+type AlertConfig struct {
+	Id              string                `json:"id"`
+	GroupId         string                `json:"groupId"`
+	EventTypeName   string                `json:"eventTypeName"`
+	Enabled         bool                  `json:"enabled"`
+	Matchers        []AlertMatcher        `json:"matchers"`
+	Notifications   []AlertNotification   `json:"notifications"`
+	MetricThreshold *AlertMetricThreshold `json:"metricThreshold"`
+}
+
+type AlertMatcher struct {
+	FieldName string `json:"fieldName"`
+	Operator  string `json:"operator"`
+	Value     string `json:"value"`
+}
+
+type AlertNotification struct {
+	TypeName     string `json:"typeName"`
+	IntervalMin  int    `json:"intervalMin"`
+	DelayMin     int    `json:"delayMin"`
+	EmailAddress string `json:"emailAddress"`
+	ChannelName  string `json:"channelName"`
+	Username     string `json:"username"`
+	MobileNumber string `json:"mobileNumber"`
+}
+
+type AlertMetricThreshold struct {
+	MetricName string  `json:"metricName"`
+	Operator   string  `json:"operator"`
+	Threshold  float64 `json:"threshold"`
+	Units      string  `json:"units"`
+	Mode       string  `json:"mode"`
+}
+
+type AlertConfigsResponse struct {
+	AlertConfigs []AlertConfig `json:"results"`
+}
+
+// MetricName returns the metric of a metric threshold alert, or "".
+func (config AlertConfig) MetricName() string {
+	if config.MetricThreshold == nil {
+		return ""
+	}
+
+	return config.MetricThreshold.MetricName
+}
+
+// Notifies returns true if the alert has a notification of the type, and
+// the target if one is given. The target is compared with the channel name,
+// email address, username and mobile number of the notification.
+func (config AlertConfig) Notifies(typeName string, target string) bool {
+	for _, notification := range config.Notifications {
+		if notification.TypeName != typeName {
+			continue
+		}
+
+		if target == "" || target == notification.ChannelName || target == notification.EmailAddress ||
+			target == notification.Username || target == notification.MobileNumber {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,83 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	AlertOpen     = "OPEN"
+	AlertTracking = "TRACKING"
+	AlertClosed   = "CLOSED"
+)
+
+type Alert struct {
+	Id                     string      `json:"id"`
+	GroupId                string      `json:"groupId"`
+	AlertConfigId          string      `json:"alertConfigId"`
+	TypeName               string      `json:"typeName"`
+	EventTypeName          string      `json:"eventTypeName"`
+	Status                 string      `json:"status"`
+	Created                time.Time   `json:"created"`
+	Updated                time.Time   `json:"updated"`
+	AcknowledgedUntil      time.Time   `json:"acknowledgedUntil"`
+	AcknowledgementComment string      `json:"acknowledgementComment"`
+	AcknowledgingUsername  string      `json:"acknowledgingUsername"`
+	HostId                 string      `json:"hostId"`
+	HostnameAndPort        string      `json:"hostnameAndPort"`
+	ReplicaSetName         string      `json:"replicaSetName"`
+	MetricName             string      `json:"metricName"`
+	CurrentValue           *AlertValue `json:"currentValue"`
+}
+
+type AlertValue struct {
+	Number float64 `json:"number"`
+	Units  string  `json:"units"`
+}
+
+type AlertsResponse struct {
+	Alerts []Alert `json:"results"`
+}
+
+// IsAcknowledged returns true if the alert is acknowledged at the given time.
+func (alert Alert) IsAcknowledged(now time.Time) bool {
+	return alert.AcknowledgedUntil.After(now)
+}
+
+// Subject returns what the alert is about: the host, the replica set or the
+// whole group.
+func (alert Alert) Subject() string {
+	if alert.HostnameAndPort != "" {
+		return alert.HostnameAndPort
+	}
+
+	if alert.ReplicaSetName != "" {
+		return alert.ReplicaSetName
+	}
+
+	return "group"
+}
+
+// String describes the alert on a single line.
+func (alert Alert) String() string {
+	text := fmt.Sprintf("%v %v on %v", alert.TypeName, alert.EventTypeName, alert.Subject())
+	if alert.MetricName != "" {
+		text += " " + alert.MetricName
+		if alert.CurrentValue != nil {
+			text += fmt.Sprintf(" %v %v", alert.CurrentValue.Number, alert.CurrentValue.Units)
+		}
+	}
+
+	return text + " since " + alert.Created.Format(time.RFC3339)
+}
+
+// AlertAcknowledgement is the body of a request acknowledging an alert. An
+// empty AcknowledgedUntil removes the acknowledgement.
+type AlertAcknowledgement struct {
+	AcknowledgedUntil      string `json:"acknowledgedUntil"`
+	AcknowledgementComment string `json:"acknowledgementComment,omitempty"`
+}
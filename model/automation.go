@@ -0,0 +1,19 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package model
+
+// AutomationStatus is the version of the automation configuration the
+// automation agents are working towards, and how far each process got.
+type AutomationStatus struct {
+	GoalVersion int                 `json:"goalVersion"`
+	Processes   []AutomationProcess `json:"processes"`
+}
+
+type AutomationProcess struct {
+	Name                    string   `json:"name"`
+	Hostname                string   `json:"hostname"`
+	LastGoalVersionAchieved int      `json:"lastGoalVersionAchieved"`
+	Plan                    []string `json:"plan"`
+}
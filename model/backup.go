@@ -0,0 +1,89 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package model
+
+import (
+	"time"
+)
+
+const (
+	BackupStarted      = "STARTED"
+	BackupStopped      = "STOPPED"
+	BackupTerminating  = "TERMINATING"
+	BackupProvisioning = "PROVISIONING"
+	BackupInactive     = "INACTIVE"
+)
+
+// Cluster is a replica set or sharded cluster, which is what is backed up.
+type Cluster struct {
+	Id             string    `json:"id"`
+	GroupId        string    `json:"groupId"`
+	TypeName       string    `json:"typeName"`
+	ClusterName    string    `json:"clusterName"`
+	ShardName      string    `json:"shardName"`
+	ReplicaSetName string    `json:"replicaSetName"`
+	LastHeartbeat  time.Time `json:"lastHeartbeat"`
+}
+
+type ClustersResponse struct {
+	Clusters []Cluster `json:"results"`
+}
+
+// Name returns the name of a sharded cluster, or of the replica set.
+func (cluster Cluster) Name() string {
+	if cluster.ClusterName != "" {
+		return cluster.ClusterName
+	}
+
+	return cluster.ReplicaSetName
+}
+
+type BackupConfig struct {
+	GroupId           string `json:"groupId"`
+	ClusterId         string `json:"clusterId"`
+	StatusName        string `json:"statusName"`
+	StorageEngineName string `json:"storageEngineName"`
+	SyncSource        string `json:"syncSource"`
+}
+
+type SnapshotSchedule struct {
+	GroupId                    string `json:"groupId"`
+	ClusterId                  string `json:"clusterId"`
+	SnapshotIntervalHours      int    `json:"snapshotIntervalHours"`
+	SnapshotRetentionDays      int    `json:"snapshotRetentionDays"`
+	DailySnapshotRetentionDays int    `json:"dailySnapshotRetentionDays"`
+	PointInTimeWindowHours     int    `json:"pointInTimeWindowHours"`
+}
+
+// BSONTimestamp is the time of the oplog entry a snapshot was taken at.
+type BSONTimestamp struct {
+	Date      time.Time `json:"date"`
+	Increment int       `json:"increment"`
+}
+
+type Snapshot struct {
+	Id                     string         `json:"id"`
+	GroupId                string         `json:"groupId"`
+	ClusterId              string         `json:"clusterId"`
+	Created                BSONTimestamp  `json:"created"`
+	Expires                time.Time      `json:"expires"`
+	Complete               bool           `json:"complete"`
+	IsPossiblyInconsistent bool           `json:"isPossiblyInconsistent"`
+	Parts                  []SnapshotPart `json:"parts"`
+}
+
+type SnapshotPart struct {
+	TypeName         string `json:"typeName"`
+	ClusterId        string `json:"clusterId"`
+	ReplicaSetName   string `json:"replicaSetName"`
+	MongodVersion    string `json:"mongodVersion"`
+	DataSizeBytes    int64  `json:"dataSizeBytes"`
+	StorageSizeBytes int64  `json:"storageSizeBytes"`
+	FileSizeBytes    int64  `json:"fileSizeBytes"`
+}
+
+type SnapshotsResponse struct {
+	Snapshots []Snapshot `json:"results"`
+}
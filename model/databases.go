@@ -0,0 +1,13 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package model
+
+type Database struct {
+	DatabaseName string `json:"databaseName"`
+}
+
+type DatabasesResponse struct {
+	Databases []Database `json:"results"`
+}
@@ -0,0 +1,50 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+type Event struct {
+	Id             string    `json:"id"`
+	GroupId        string    `json:"groupId"`
+	EventTypeName  string    `json:"eventTypeName"`
+	Created        time.Time `json:"created"`
+	HostId         string    `json:"hostId"`
+	Hostname       string    `json:"hostname"`
+	Port           int       `json:"port"`
+	ReplicaSetName string    `json:"replicaSetName"`
+	Username       string    `json:"username"`
+	TargetUsername string    `json:"targetUsername"`
+	RemoteAddress  string    `json:"remoteAddress"`
+}
+
+type EventsResponse struct {
+	Events []Event `json:"results"`
+}
+
+// String describes the event on a single line.
+func (event Event) String() string {
+	text := fmt.Sprintf("%v %v", event.Created.Format(time.RFC3339), event.EventTypeName)
+	if event.Hostname != "" {
+		text += fmt.Sprintf(" on %v:%v", event.Hostname, event.Port)
+	}
+
+	if event.ReplicaSetName != "" {
+		text += fmt.Sprintf(" in %v", event.ReplicaSetName)
+	}
+
+	if event.Username != "" {
+		text += " by " + event.Username
+	}
+
+	if event.TargetUsername != "" {
+		text += " for " + event.TargetUsername
+	}
+
+	return text
+}
@@ -0,0 +1,22 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package model
+
+import (
+	"time"
+)
+
+type Group struct {
+	Id               string    `json:"id"`
+	Name             string    `json:"name"`
+	ActiveAgentCount int       `json:"activeAgentCount"`
+	ReplicaSetCount  int       `json:"replicaSetCount"`
+	ShardCount       int       `json:"shardCount"`
+	LastActiveAgent  time.Time `json:"lastActiveAgent"`
+}
+
+type GroupsResponse struct {
+	Groups []Group `json:"results"`
+}
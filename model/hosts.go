@@ -5,14 +5,69 @@
 package model
 
 import (
+	"fmt"
 	"time"
 )
 
+const (
+	RolePrimary    = "primary"
+	RoleSecondary  = "secondary"
+	RoleArbiter    = "arbiter"
+	RoleMongos     = "mongos"
+	RoleConfig     = "config"
+	RoleStandalone = "standalone"
+	RoleUnknown    = "unknown"
+)
+
 type Host struct {
-	Id       string    `json:"id"`
-	LastPing time.Time `json:"lastPing"`
+	Id               string    `json:"id"`
+	GroupId          string    `json:"groupId"`
+	Hostname         string    `json:"hostname"`
+	Port             int       `json:"port"`
+	TypeName         string    `json:"typeName"`
+	ReplicaSetName   string    `json:"replicaSetName"`
+	ReplicaStateName string    `json:"replicaStateName"`
+	ShardName        string    `json:"shardName"`
+	ClusterId        string    `json:"clusterId"`
+	Version          string    `json:"version"`
+	LastPing         time.Time `json:"lastPing"`
 }
 
 type HostsResponse struct {
 	Hosts []Host `json:"results"`
 }
+
+// Name returns the hostname:port the host is known by in MMS/Ops Manager.
+func (host Host) Name() string {
+	return fmt.Sprintf("%v:%v", host.Hostname, host.Port)
+}
+
+// Role maps the MMS/Ops Manager host type to the role the host plays in its
+// deployment.
+func (host Host) Role() string {
+	switch host.TypeName {
+	case "REPLICA_PRIMARY", "SHARD_PRIMARY":
+		return RolePrimary
+	case "REPLICA_SECONDARY", "SHARD_SECONDARY":
+		return RoleSecondary
+	case "REPLICA_ARBITER", "SHARD_ARBITER":
+		return RoleArbiter
+	case "SHARD_MONGOS":
+		return RoleMongos
+	case "SHARD_CONFIG", "SHARD_CONFIG_PRIMARY", "SHARD_CONFIG_SECONDARY":
+		return RoleConfig
+	case "STANDALONE", "SHARD_STANDALONE":
+		return RoleStandalone
+	}
+
+	switch host.ReplicaStateName {
+	case "PRIMARY":
+		return RolePrimary
+	case "SECONDARY":
+		return RoleSecondary
+	case "ARBITER":
+		return RoleArbiter
+	}
+
+	return RoleUnknown
+}
@@ -0,0 +1,30 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package model
+
+import (
+	"time"
+)
+
+// AlertTypeNames are the kinds of alerts a maintenance window can silence.
+var AlertTypeNames = []string{"AGENT", "BACKUP", "CLUSTER", "HOST", "REPLICA_SET"}
+
+type MaintenanceWindow struct {
+	Id             string    `json:"id,omitempty"`
+	GroupId        string    `json:"groupId,omitempty"`
+	StartDate      time.Time `json:"startDate"`
+	EndDate        time.Time `json:"endDate"`
+	AlertTypeNames []string  `json:"alertTypeNames"`
+	Description    string    `json:"description,omitempty"`
+}
+
+type MaintenanceWindowsResponse struct {
+	MaintenanceWindows []MaintenanceWindow `json:"results"`
+}
+
+// IsActive returns true if the window silences alerts at the given time.
+func (window MaintenanceWindow) IsActive(now time.Time) bool {
+	return !now.Before(window.StartDate) && now.Before(window.EndDate)
+}
@@ -6,13 +6,27 @@ package model
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 )
 
+const (
+	RatePerSecond   = "second"
+	RatePerInterval = "interval"
+)
+
 type Metric struct {
 	MetricName string      `json:"metricName"`
 	Units      string      `json:"units"`
 	DataPoints []DataPoint `json:"dataPoints"`
+
+	// per is set on a metric created by Rate.
+	per string
+}
+
+type MetricsResponse struct {
+	Metrics []Metric `json:"results"`
 }
 
 type DataPoint struct {
@@ -52,6 +66,16 @@ var metricFormaters = map[string]string{
 	"DB_STORAGE_TOTAL":                    "%v bytes of on-disk storage used",
 	"DB_DATA_SIZE_TOTAL":                  "%v bytes of data stored",
 	"DB_PAGE_FAULT_EXCEPTIONS_THROWN":     "%v page fault exceptions per second",
+	"DISK_PARTITION_IOPS_READ":            "%v disk reads per second",
+	"DISK_PARTITION_IOPS_WRITE":           "%v disk writes per second",
+	"DISK_PARTITION_IOPS_TOTAL":           "%v disk operations per second",
+	"DISK_PARTITION_UTILIZATION":          "%v percent disk utilization",
+	"DISK_PARTITION_LATENCY_READ":         "%v millisecond disk read latency",
+	"DISK_PARTITION_LATENCY_WRITE":        "%v millisecond disk write latency",
+	"DISK_PARTITION_SPACE_FREE":           "%v bytes of disk space free",
+	"DISK_PARTITION_SPACE_USED":           "%v bytes of disk space used",
+	"DISK_PARTITION_SPACE_PERCENT_FREE":   "%v percent of disk space free",
+	"DISK_PARTITION_SPACE_PERCENT_USED":   "%v percent of disk space used",
 	"EFFECTIVE_LOCK_PERCENTAGE":           "%v effective lock percentage",
 	"EXTRA_INFO_PAGE_FAULTS":              "%v page faults per second",
 	"GLOBAL_ACCESSES_NOT_IN_MEMORY":       "%v not in memory page accesses per second",
@@ -85,6 +109,81 @@ var metricFormaters = map[string]string{
 	"OPLOG_MASTER_LAG_TIME_DIFF":          "%v seconds of replication headroom",
 }
 
+// IsKnownMetric returns true if the metric has a formater.
+func IsKnownMetric(metricName string) bool {
+	_, ok := metricFormaters[metricName]
+	return ok
+}
+
+// KnownMetricNames returns the sorted names of the metrics that have a
+// formater.
+func KnownMetricNames() []string {
+	names := make([]string, 0, len(metricFormaters))
+	for name := range metricFormaters {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// IsDBMetric returns true if the metric is collected per database and must
+// be queried with a database name.
+func IsDBMetric(metricName string) bool {
+	return strings.HasPrefix(metricName, "DB_")
+}
+
+// IsDiskMetric returns true if the metric is collected per disk partition and
+// must be queried with a partition name.
+func IsDiskMetric(metricName string) bool {
+	return strings.HasPrefix(metricName, "DISK_PARTITION_")
+}
+
+// cumulativeMetrics count since the process started.
+var cumulativeMetrics = map[string]bool{
+	"ASSERT_MSG":              true,
+	"ASSERT_REGULAR":          true,
+	"ASSERT_USER":             true,
+	"ASSERT_WARNING":          true,
+	"CURSORS_TOTAL_TIMED_OUT": true,
+}
+
+// IsCumulativeMetric returns true if the metric counts since the process
+// started, so it is only meaningful as a rate.
+func IsCumulativeMetric(metricName string) bool {
+	return cumulativeMetrics[metricName]
+}
+
+// Rate converts the data points of a cumulative metric into its increase per
+// second, or per interval between consecutive data points. A decrease is a
+// counter reset caused by a process restart, and the value after it is taken
+// as the increase since the restart. It also returns the number of resets.
+func (metric *Metric) Rate(per string) (*Metric, int) {
+	rate := &Metric{MetricName: metric.MetricName, Units: metric.Units, per: per}
+	resets := 0
+	for i := 1; i < len(metric.DataPoints); i++ {
+		previous, current := metric.DataPoints[i-1], metric.DataPoints[i]
+		seconds := current.Timestamp.Sub(previous.Timestamp).Seconds()
+		if seconds <= 0 {
+			continue
+		}
+
+		increase := current.Value - previous.Value
+		if increase < 0 {
+			increase = current.Value
+			resets++
+		}
+
+		if per == RatePerSecond {
+			increase /= seconds
+		}
+
+		rate.DataPoints = append(rate.DataPoints, DataPoint{Timestamp: current.Timestamp, Value: increase})
+	}
+
+	return rate, resets
+}
+
 func (metric *Metric) ToStringLastDataPoint() string {
 	if len(metric.DataPoints) == 0 {
 		return "Metric has no datapoints"
@@ -94,6 +193,10 @@ func (metric *Metric) ToStringLastDataPoint() string {
 }
 
 func (metric *Metric) ToStringDataPoint(index int) string {
+	if metric.per != "" {
+		return fmt.Sprintf("%v increased by %v per %v", metric.MetricName, metric.DataPoints[index].Value, metric.per)
+	}
+
 	metricFormater, ok := metricFormaters[metric.MetricName]
 	if ok == false {
 		return fmt.Sprintf("%v %v %v", metric.MetricName, metric.DataPoints[index].Value, metricUnits[metric.Units])
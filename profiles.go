@@ -0,0 +1,172 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"./model"
+	"./util"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+var profilesFile string
+var activeProfile string
+
+// thresholdProfile replaces -w and -c while its schedule matches, on hosts
+// with one of its roles. A profile without a metric applies to every metric,
+// one without roles to every host and one without a schedule at all times.
+// Warning or critical left empty keep the range given on the command line. A
+// profile with skip set means the metric doesn't apply, and the check is OK
+// without looking at the metric.
+type thresholdProfile struct {
+	Name     string   `json:"name"`
+	Metric   string   `json:"metric,omitempty"`
+	Roles    []string `json:"roles,omitempty"`
+	Schedule string   `json:"schedule,omitempty"`
+	Timezone string   `json:"timezone,omitempty"`
+	Warning  string   `json:"warning,omitempty"`
+	Critical string   `json:"critical,omitempty"`
+	Skip     bool     `json:"skip,omitempty"`
+}
+
+// profileConfig is the file given with --profiles. The timezone applies to
+// profiles that don't have their own, and defaults to the local time zone.
+type profileConfig struct {
+	Timezone string             `json:"timezone,omitempty"`
+	Profiles []thresholdProfile `json:"profiles"`
+}
+
+// applyProfile replaces the warning and critical ranges with those of the
+// first profile for the metric and the role of the host that is active at
+// the given time, and remembers its name in activeProfile. It returns true if
+// the profile skips the metric.
+func applyProfile(metricName string, host *model.Host, now time.Time) (bool, error) {
+	if profilesFile == "" {
+		return false, nil
+	}
+
+	config, err := loadProfiles(profilesFile)
+	if err != nil {
+		return false, err
+	}
+
+	for _, profile := range config.Profiles {
+		if profile.Metric != "" && profile.Metric != metricName {
+			continue
+		}
+
+		if !profile.appliesTo(host) {
+			continue
+		}
+
+		active, err := profile.activeAt(now, config.Timezone)
+		if err != nil {
+			return false, err
+		}
+
+		if !active {
+			continue
+		}
+
+		activeProfile = profile.Name
+		if profile.Skip {
+			return true, nil
+		}
+
+		if profile.Warning != "" {
+			warning = profile.Warning
+		}
+		if profile.Critical != "" {
+			critical = profile.Critical
+		}
+		return false, nil
+	}
+
+	return false, nil
+}
+
+// withProfile adds the name of the active profile, if any, to the message.
+func withProfile(message string) string {
+	if activeProfile == "" {
+		return message
+	}
+
+	return fmt.Sprintf("%v (profile %v)", message, activeProfile)
+}
+
+// appliesTo returns true if the profile has no roles or one of them is the
+// role of the host.
+func (profile thresholdProfile) appliesTo(host *model.Host) bool {
+	if len(profile.Roles) == 0 {
+		return true
+	}
+
+	for _, role := range profile.Roles {
+		if role == host.Role() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// activeAt returns true if the schedule of the profile matches the time in
+// the profile's time zone, or the default one.
+func (profile thresholdProfile) activeAt(now time.Time, defaultTimezone string) (bool, error) {
+	if profile.Schedule == "" {
+		return true, nil
+	}
+
+	schedule, err := util.ParseSchedule(profile.Schedule)
+	if err != nil {
+		return false, errors.New(fmt.Sprintf("Error in profile %v. Error: %v", profile.Name, err))
+	}
+
+	timezone := profile.Timezone
+	if timezone == "" {
+		timezone = defaultTimezone
+	}
+
+	location := time.Local
+	if timezone != "" {
+		if location, err = time.LoadLocation(timezone); err != nil {
+			return false, errors.New(fmt.Sprintf("Error in profile %v. Error: %v", profile.Name, err))
+		}
+	}
+
+	return schedule.Matches(now.In(location)), nil
+}
+
+func loadProfiles(profilesFile string) (*profileConfig, error) {
+	buffer, err := ioutil.ReadFile(profilesFile)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Failed to read profiles %v. Error: %v", profilesFile, err))
+	}
+
+	config := &profileConfig{}
+	if err := json.Unmarshal(buffer, config); err != nil {
+		return nil, errors.New(fmt.Sprintf("Failed to parse profiles %v. Error: %v", profilesFile, err))
+	}
+
+	return config, nil
+}
+
+const (
+	profilesUsage = "JSON file of threshold profiles that replace -w and -c on a schedule or for a host role"
+)
+
+func setupProfileFlags() {
+	flag.StringVar(&profilesFile, "profiles", "", profilesUsage)
+}
+
+func profileUsage() {
+	fmt.Fprintf(os.Stdout, "\n     threshold profiles of -m checks, the first active profile for the metric and host role applies:\n")
+	fmt.Fprintf(os.Stdout, "     --profiles %v\n", profilesUsage)
+}
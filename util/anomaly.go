@@ -0,0 +1,90 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"../model"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	AnomalyZScore = "zscore"
+	AnomalyMAD    = "mad"
+
+	// MinAnomalySamples is the fewest historic data points the last data
+	// point is compared against.
+	MinAnomalySamples = 3
+
+	// madScale makes the median absolute deviation comparable to a standard
+	// deviation for normally distributed values.
+	madScale = 1.4826
+)
+
+// Anomaly is how far the last data point of a series is from the data points
+// before it, in standard deviations (zscore) or scaled median absolute
+// deviations (mad).
+type Anomaly struct {
+	Score   float64
+	Value   float64
+	Center  float64
+	Spread  float64
+	Samples int
+}
+
+// DetectAnomaly scores the last data point against the data points before it.
+// When seasonal is true only data points from the same hour of the week (in
+// UTC) are used, so a weekly pattern doesn't look anomalous. The result only
+// depends on the data points, not on the current time.
+func DetectAnomaly(points []model.DataPoint, method string, seasonal bool) (Anomaly, error) {
+	if len(points) == 0 {
+		return Anomaly{}, errors.New("No data points to detect an anomaly in")
+	}
+
+	last := points[len(points)-1]
+	history := make([]float64, 0, len(points)-1)
+	for _, point := range points[:len(points)-1] {
+		if !seasonal || hourOfWeek(point.Timestamp) == hourOfWeek(last.Timestamp) {
+			history = append(history, point.Value)
+		}
+	}
+
+	if len(history) < MinAnomalySamples {
+		return Anomaly{}, errors.New(fmt.Sprintf("Only %v historic data points found, at least %v are needed", len(history), MinAnomalySamples))
+	}
+
+	anomaly := Anomaly{Value: last.Value, Samples: len(history)}
+	switch method {
+	case AnomalyZScore:
+		anomaly.Center = Mean(history)
+		anomaly.Spread = StdDev(history)
+	case AnomalyMAD:
+		anomaly.Center = Median(history)
+		deviations := make([]float64, len(history))
+		for i, value := range history {
+			deviations[i] = math.Abs(value - anomaly.Center)
+		}
+		anomaly.Spread = madScale * Median(deviations)
+	default:
+		return Anomaly{}, errors.New(fmt.Sprintf("Unknown anomaly method %v", method))
+	}
+
+	difference := last.Value - anomaly.Center
+	switch {
+	case anomaly.Spread != 0:
+		anomaly.Score = difference / anomaly.Spread
+	case difference != 0:
+		anomaly.Score = math.Inf(int(math.Copysign(1, difference)))
+	}
+
+	return anomaly, nil
+}
+
+func hourOfWeek(t time.Time) int {
+	t = t.UTC()
+	return int(t.Weekday())*24 + t.Hour()
+}
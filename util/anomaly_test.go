@@ -0,0 +1,97 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"../model"
+	"math"
+	"testing"
+	"time"
+)
+
+// weekly returns data points an hour apart over the weeks, 100 at the hour
+// of the week of the origin and 10 otherwise, followed by the last value at
+// that hour again. The peaks before the last value are given by peaks.
+func weekly(peaks []float64, last float64) []model.DataPoint {
+	var points []model.DataPoint
+	for hour := 0; hour < len(peaks)*168; hour++ {
+		value := 10.0
+		if hour%168 == 0 {
+			value = peaks[hour/168]
+		}
+		points = append(points, model.DataPoint{Timestamp: origin.Add(time.Duration(hour) * time.Hour), Value: value})
+	}
+
+	return append(points, model.DataPoint{Timestamp: origin.Add(time.Duration(len(peaks)*168) * time.Hour), Value: last})
+}
+
+func TestDetectAnomaly(t *testing.T) {
+	tests := []struct {
+		name     string
+		points   []model.DataPoint
+		method   string
+		seasonal bool
+		score    float64
+		samples  int
+	}{
+		{"zscore", series(10, 12, 11, 13, 9, 20), AnomalyZScore, false, 9 / math.Sqrt(2), 5},
+		{"zscore below", series(10, 12, 11, 13, 9, 2), AnomalyZScore, false, -9 / math.Sqrt(2), 5},
+		{"mad ignores an earlier spike", series(10, 12, 11, 13, 100, 20), AnomalyMAD, false, 8 / madScale, 5},
+		{"zscore zero spread above", series(5, 5, 5, 6), AnomalyZScore, false, math.Inf(1), 3},
+		{"mad zero spread below", series(5, 5, 5, 4), AnomalyMAD, false, math.Inf(-1), 3},
+		{"zero spread no difference", series(5, 5, 5, 5), AnomalyZScore, false, 0, 3},
+		{"seasonal peak", weekly([]float64{100, 110, 90}, 100), AnomalyZScore, true, 0, 3},
+		{"seasonal spike", weekly([]float64{100, 110, 90}, 130), AnomalyZScore, true, 30 / math.Sqrt(200.0/3), 3},
+		{"seasonal mad", weekly([]float64{100, 110, 90}, 130), AnomalyMAD, true, 30 / (10 * madScale), 3},
+	}
+
+	for _, test := range tests {
+		anomaly, err := DetectAnomaly(test.points, test.method, test.seasonal)
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+
+		if anomaly.Samples != test.samples {
+			t.Errorf("%v: %v samples, expected %v", test.name, anomaly.Samples, test.samples)
+		}
+		if (math.IsInf(test.score, 0) && anomaly.Score != test.score) || math.Abs(anomaly.Score-test.score) > 1e-9 {
+			t.Errorf("%v: score %v, expected %v", test.name, anomaly.Score, test.score)
+		}
+		if anomaly.Value != test.points[len(test.points)-1].Value {
+			t.Errorf("%v: value %v, expected the last data point", test.name, anomaly.Value)
+		}
+	}
+}
+
+func TestDetectAnomalyErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		points   []model.DataPoint
+		method   string
+		seasonal bool
+	}{
+		{"no data points", nil, AnomalyZScore, false},
+		{"too few samples", series(1, 2, 3), AnomalyZScore, false},
+		{"too few seasonal samples", weekly([]float64{100, 100}, 100), AnomalyMAD, true},
+		{"unknown method", series(1, 2, 3, 4), "mean", false},
+	}
+
+	for _, test := range tests {
+		if anomaly, err := DetectAnomaly(test.points, test.method, test.seasonal); err == nil {
+			t.Errorf("%v: expected an error, got %+v", test.name, anomaly)
+		}
+	}
+}
+
+func TestDetectAnomalyIsDeterministic(t *testing.T) {
+	points := weekly([]float64{100, 110, 90}, 130)
+	first, _ := DetectAnomaly(points, AnomalyMAD, true)
+	for i := 0; i < 10; i++ {
+		if anomaly, _ := DetectAnomaly(points, AnomalyMAD, true); anomaly != first {
+			t.Fatalf("%+v differs from %+v", anomaly, first)
+		}
+	}
+}
@@ -6,22 +6,70 @@ package util
 
 import (
 	"../model"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
 
+const (
+	// ItemsPerPage is the number of results requested per page of a list.
+	ItemsPerPage = 100
+)
+
 type MMSAPI struct {
 	client   *http.Client
 	hostname string
 }
 
+// MetricQuery selects the data points returned for a metric. The zero value
+// returns the data points the API returns by default.
+type MetricQuery struct {
+	Granularity string
+	Period      string
+	Start       time.Time
+	End         time.Time
+}
+
+func (query MetricQuery) String() string {
+	values := url.Values{}
+	if query.Granularity != "" {
+		values.Set("granularity", query.Granularity)
+	}
+
+	if query.Period != "" {
+		values.Set("period", query.Period)
+	}
+
+	if !query.Start.IsZero() {
+		values.Set("start", query.Start.UTC().Format(time.RFC3339))
+	}
+
+	if !query.End.IsZero() {
+		values.Set("end", query.End.UTC().Format(time.RFC3339))
+	}
+
+	if len(values) == 0 {
+		return ""
+	}
+
+	return "?" + values.Encode()
+}
+
+// PeriodOfDays returns the ISO 8601 duration of the given number of days
+// used by the period of a MetricQuery.
+func PeriodOfDays(days int) string {
+	return fmt.Sprintf("P%vD", days)
+}
+
 func NewMMSAPI(hostname string, timeout int, username string, apiKey string) (*MMSAPI, error) {
 	t := NewTransport(username, apiKey)
 	c, err := t.Client()
@@ -51,18 +99,36 @@ func NewMMSAPI(hostname string, timeout int, username string, apiKey string) (*M
 	return &MMSAPI{client: c, hostname: hostname}, nil
 }
 
-func (api *MMSAPI) GetAllHosts(groupId string) ([]model.Host, error) {
-	body, err := api.doGet(fmt.Sprintf("/groups/%v/hosts", groupId))
+func (api *MMSAPI) GetGroups() ([]model.Group, error) {
+	body, err := api.doGet("/groups")
 	if err != nil {
 		return nil, err
 	}
 
-	hostResp := &model.HostsResponse{}
-	if err := unMarshalJSON(body, &hostResp); err != nil {
+	groupsResp := &model.GroupsResponse{}
+	if err := unMarshalJSON(body, &groupsResp); err != nil {
 		return nil, err
 	}
 
-	return hostResp.Hosts, nil
+	return groupsResp.Groups, nil
+}
+
+func (api *MMSAPI) GetAllHosts(groupId string) ([]model.Host, error) {
+	var hosts []model.Host
+	err := api.doGetPages(fmt.Sprintf("/groups/%v/hosts", groupId), nil, func(body []byte) (int, error) {
+		hostResp := &model.HostsResponse{}
+		if err := unMarshalJSON(body, &hostResp); err != nil {
+			return 0, err
+		}
+
+		hosts = append(hosts, hostResp.Hosts...)
+		return len(hostResp.Hosts), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return hosts, nil
 }
 
 func (api *MMSAPI) GetHostByName(groupId string, name string) (*model.Host, error) {
@@ -79,8 +145,40 @@ func (api *MMSAPI) GetHostByName(groupId string, name string) (*model.Host, erro
 	return host, nil
 }
 
-func (api *MMSAPI) GetHostMetric(groupId string, hostId string, metricName string) (*model.Metric, error) {
-	body, err := api.doGet(fmt.Sprintf("/groups/%v/hosts/%v/metrics/%v", groupId, hostId, metricName))
+func (api *MMSAPI) GetHostMetrics(groupId string, hostId string) ([]model.Metric, error) {
+	var metrics []model.Metric
+	err := api.doGetPages(fmt.Sprintf("/groups/%v/hosts/%v/metrics", groupId, hostId), nil, func(body []byte) (int, error) {
+		metricsResp := &model.MetricsResponse{}
+		if err := unMarshalJSON(body, &metricsResp); err != nil {
+			return 0, err
+		}
+
+		metrics = append(metrics, metricsResp.Metrics...)
+		return len(metricsResp.Metrics), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return metrics, nil
+}
+
+func (api *MMSAPI) GetHostMetric(groupId string, hostId string, metricName string, query MetricQuery) (*model.Metric, error) {
+	body, err := api.doGet(fmt.Sprintf("/groups/%v/hosts/%v/metrics/%v%v", groupId, hostId, metricName, query))
+	if err != nil {
+		return nil, err
+	}
+
+	metric := &model.Metric{}
+	if err := unMarshalJSON(body, &metric); err != nil {
+		return nil, err
+	}
+
+	return metric, nil
+}
+
+func (api *MMSAPI) GetHostDBMetric(groupId string, hostId string, metricName string, dbName string, query MetricQuery) (*model.Metric, error) {
+	body, err := api.doGet(fmt.Sprintf("/groups/%v/hosts/%v/metrics/%v/%v%v", groupId, hostId, metricName, escape(dbName), query))
 	if err != nil {
 		return nil, err
 	}
@@ -93,8 +191,62 @@ func (api *MMSAPI) GetHostMetric(groupId string, hostId string, metricName strin
 	return metric, nil
 }
 
-func (api *MMSAPI) GetHostDBMetric(groupId string, hostId string, metricName string, dbName string) (*model.Metric, error) {
-	body, err := api.doGet(fmt.Sprintf("/groups/%v/hosts/%v/metrics/%v/%v", groupId, hostId, metricName, escape(dbName)))
+func (api *MMSAPI) GetHostDatabases(groupId string, hostId string) ([]model.Database, error) {
+	var databases []model.Database
+	err := api.doGetPages(fmt.Sprintf("/groups/%v/hosts/%v/databases", groupId, hostId), nil, func(body []byte) (int, error) {
+		databasesResp := &model.DatabasesResponse{}
+		if err := unMarshalJSON(body, &databasesResp); err != nil {
+			return 0, err
+		}
+
+		databases = append(databases, databasesResp.Databases...)
+		return len(databasesResp.Databases), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return databases, nil
+}
+
+func (api *MMSAPI) GetHostDisks(groupId string, hostId string) ([]model.Disk, error) {
+	var disks []model.Disk
+	err := api.doGetPages(fmt.Sprintf("/groups/%v/hosts/%v/disks", groupId, hostId), nil, func(body []byte) (int, error) {
+		disksResp := &model.DisksResponse{}
+		if err := unMarshalJSON(body, &disksResp); err != nil {
+			return 0, err
+		}
+
+		disks = append(disks, disksResp.Disks...)
+		return len(disksResp.Disks), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return disks, nil
+}
+
+func (api *MMSAPI) GetHostDiskMetrics(groupId string, hostId string, partitionName string) ([]model.Metric, error) {
+	var metrics []model.Metric
+	err := api.doGetPages(fmt.Sprintf("/groups/%v/hosts/%v/disks/%v/metrics", groupId, hostId, escape(partitionName)), nil, func(body []byte) (int, error) {
+		metricsResp := &model.MetricsResponse{}
+		if err := unMarshalJSON(body, &metricsResp); err != nil {
+			return 0, err
+		}
+
+		metrics = append(metrics, metricsResp.Metrics...)
+		return len(metricsResp.Metrics), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return metrics, nil
+}
+
+func (api *MMSAPI) GetHostDiskMetric(groupId string, hostId string, partitionName string, metricName string, query MetricQuery) (*model.Metric, error) {
+	body, err := api.doGet(fmt.Sprintf("/groups/%v/hosts/%v/disks/%v/metrics/%v%v", groupId, hostId, escape(partitionName), metricName, query))
 	if err != nil {
 		return nil, err
 	}
@@ -107,10 +259,293 @@ func (api *MMSAPI) GetHostDBMetric(groupId string, hostId string, metricName str
 	return metric, nil
 }
 
+// GetAlerts returns the alerts of the group with the given status, or all
+// alerts if status is "".
+func (api *MMSAPI) GetAlerts(groupId string, status string) ([]model.Alert, error) {
+	values := url.Values{}
+	if status != "" {
+		values.Set("status", status)
+	}
+
+	var alerts []model.Alert
+	err := api.doGetPages(fmt.Sprintf("/groups/%v/alerts", groupId), values, func(body []byte) (int, error) {
+		alertsResp := &model.AlertsResponse{}
+		if err := unMarshalJSON(body, &alertsResp); err != nil {
+			return 0, err
+		}
+
+		alerts = append(alerts, alertsResp.Alerts...)
+		return len(alertsResp.Alerts), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return alerts, nil
+}
+
+func (api *MMSAPI) GetAlert(groupId string, alertId string) (*model.Alert, error) {
+	body, err := api.doGet(fmt.Sprintf("/groups/%v/alerts/%v", groupId, alertId))
+	if err != nil {
+		return nil, err
+	}
+
+	alert := &model.Alert{}
+	if err := unMarshalJSON(body, &alert); err != nil {
+		return nil, err
+	}
+
+	return alert, nil
+}
+
+// AcknowledgeAlert acknowledges the alert until the given time, which stops
+// its notifications. The zero time removes the acknowledgement.
+func (api *MMSAPI) AcknowledgeAlert(groupId string, alertId string, until time.Time, comment string) (*model.Alert, error) {
+	payload := model.AlertAcknowledgement{AcknowledgementComment: comment}
+	if !until.IsZero() {
+		payload.AcknowledgedUntil = until.UTC().Format(time.RFC3339)
+	}
+
+	body, err := api.doRequest("PATCH", fmt.Sprintf("/groups/%v/alerts/%v", groupId, alertId), payload)
+	if err != nil {
+		return nil, err
+	}
+
+	alert := &model.Alert{}
+	if err := unMarshalJSON(body, &alert); err != nil {
+		return nil, err
+	}
+
+	return alert, nil
+}
+
+func (api *MMSAPI) GetAlertConfigs(groupId string) ([]model.AlertConfig, error) {
+	var alertConfigs []model.AlertConfig
+	err := api.doGetPages(fmt.Sprintf("/groups/%v/alertConfigs", groupId), nil, func(body []byte) (int, error) {
+		configsResp := &model.AlertConfigsResponse{}
+		if err := unMarshalJSON(body, &configsResp); err != nil {
+			return 0, err
+		}
+
+		alertConfigs = append(alertConfigs, configsResp.AlertConfigs...)
+		return len(configsResp.AlertConfigs), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return alertConfigs, nil
+}
+
+func (api *MMSAPI) GetClusters(groupId string) ([]model.Cluster, error) {
+	var clusters []model.Cluster
+	err := api.doGetPages(fmt.Sprintf("/groups/%v/clusters", groupId), nil, func(body []byte) (int, error) {
+		clustersResp := &model.ClustersResponse{}
+		if err := unMarshalJSON(body, &clustersResp); err != nil {
+			return 0, err
+		}
+
+		clusters = append(clusters, clustersResp.Clusters...)
+		return len(clustersResp.Clusters), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return clusters, nil
+}
+
+func (api *MMSAPI) GetBackupConfig(groupId string, clusterId string) (*model.BackupConfig, error) {
+	body, err := api.doGet(fmt.Sprintf("/groups/%v/backupConfigs/%v", groupId, clusterId))
+	if err != nil {
+		return nil, err
+	}
+
+	config := &model.BackupConfig{}
+	if err := unMarshalJSON(body, &config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (api *MMSAPI) GetSnapshotSchedule(groupId string, clusterId string) (*model.SnapshotSchedule, error) {
+	body, err := api.doGet(fmt.Sprintf("/groups/%v/backupConfigs/%v/snapshotSchedule", groupId, clusterId))
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := &model.SnapshotSchedule{}
+	if err := unMarshalJSON(body, &schedule); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+func (api *MMSAPI) GetSnapshots(groupId string, clusterId string) ([]model.Snapshot, error) {
+	var snapshots []model.Snapshot
+	err := api.doGetPages(fmt.Sprintf("/groups/%v/clusters/%v/snapshots", groupId, clusterId), nil, func(body []byte) (int, error) {
+		snapshotsResp := &model.SnapshotsResponse{}
+		if err := unMarshalJSON(body, &snapshotsResp); err != nil {
+			return 0, err
+		}
+
+		snapshots = append(snapshots, snapshotsResp.Snapshots...)
+		return len(snapshotsResp.Snapshots), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshots, nil
+}
+
+func (api *MMSAPI) GetAutomationStatus(groupId string) (*model.AutomationStatus, error) {
+	body, err := api.doGet(fmt.Sprintf("/groups/%v/automationStatus", groupId))
+	if err != nil {
+		return nil, err
+	}
+
+	status := &model.AutomationStatus{}
+	if err := unMarshalJSON(body, &status); err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// GetAgents returns the agents of the type, MONITORING, BACKUP or AUTOMATION.
+func (api *MMSAPI) GetAgents(groupId string, agentType string) ([]model.Agent, error) {
+	var agents []model.Agent
+	err := api.doGetPages(fmt.Sprintf("/groups/%v/agents/%v", groupId, agentType), nil, func(body []byte) (int, error) {
+		agentsResp := &model.AgentsResponse{}
+		if err := unMarshalJSON(body, &agentsResp); err != nil {
+			return 0, err
+		}
+
+		agents = append(agents, agentsResp.Agents...)
+		return len(agentsResp.Agents), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return agents, nil
+}
+
+// GetEvents returns all events of the group created since the time, which
+// is only precise to the second, newest first.
+func (api *MMSAPI) GetEvents(groupId string, since time.Time) ([]model.Event, error) {
+	values := url.Values{}
+	values.Set("minDate", since.UTC().Format(time.RFC3339))
+
+	var events []model.Event
+	err := api.doGetPages(fmt.Sprintf("/groups/%v/events", groupId), values, func(body []byte) (int, error) {
+		eventsResp := &model.EventsResponse{}
+		if err := unMarshalJSON(body, &eventsResp); err != nil {
+			return 0, err
+		}
+
+		events = append(events, eventsResp.Events...)
+		return len(eventsResp.Events), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (api *MMSAPI) GetMaintenanceWindows(groupId string) ([]model.MaintenanceWindow, error) {
+	body, err := api.doGet(fmt.Sprintf("/groups/%v/maintenanceWindows", groupId))
+	if err != nil {
+		return nil, err
+	}
+
+	windowsResp := &model.MaintenanceWindowsResponse{}
+	if err := unMarshalJSON(body, &windowsResp); err != nil {
+		return nil, err
+	}
+
+	return windowsResp.MaintenanceWindows, nil
+}
+
+func (api *MMSAPI) CreateMaintenanceWindow(groupId string, window model.MaintenanceWindow) (*model.MaintenanceWindow, error) {
+	body, err := api.doRequest("POST", fmt.Sprintf("/groups/%v/maintenanceWindows", groupId), window)
+	if err != nil {
+		return nil, err
+	}
+
+	created := &model.MaintenanceWindow{}
+	if err := unMarshalJSON(body, &created); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (api *MMSAPI) DeleteMaintenanceWindow(groupId string, windowId string) error {
+	_, err := api.doRequest("DELETE", fmt.Sprintf("/groups/%v/maintenanceWindows/%v", groupId, windowId), nil)
+	return err
+}
+
 func (api *MMSAPI) doGet(path string) ([]byte, error) {
+	return api.doRequest("GET", path, nil)
+}
+
+// doGetPages requests the pages of a list with the query values, ItemsPerPage
+// results at a time, until a page isn't full. read is called with the body of
+// each page and returns the number of results on it.
+func (api *MMSAPI) doGetPages(path string, values url.Values, read func(body []byte) (int, error)) error {
+	for page := 1; ; page++ {
+		query := url.Values{}
+		for key, value := range values {
+			query[key] = value
+		}
+		query.Set("itemsPerPage", strconv.Itoa(ItemsPerPage))
+		query.Set("pageNum", strconv.Itoa(page))
+
+		body, err := api.doGet(fmt.Sprintf("%v?%v", path, query.Encode()))
+		if err != nil {
+			return err
+		}
+
+		count, err := read(body)
+		if err != nil {
+			return err
+		}
+
+		if count < ItemsPerPage {
+			return nil
+		}
+	}
+}
+
+// doRequest sends the payload, if any, as JSON and returns the body of a
+// successful response.
+func (api *MMSAPI) doRequest(method string, path string, payload interface{}) ([]byte, error) {
 	uri := fmt.Sprintf("%v/api/public/v1.0%v", api.hostname, path)
 
-	response, err := api.client.Get(uri)
+	var reader io.Reader
+	if payload != nil {
+		buffer, err := json.Marshal(payload)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Failed to encode HTTP request body. Error: %v", err))
+		}
+		reader = bytes.NewReader(buffer)
+	}
+
+	request, err := http.NewRequest(method, uri, reader)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Failed to make HTTP request. Error: %v", err))
+	}
+
+	if payload != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := api.client.Do(request)
 	if err != nil {
 		return nil, errors.New(fmt.Sprintf("Failed to make HTTP request. Error: %v", err))
 	}
@@ -121,7 +556,7 @@ func (api *MMSAPI) doGet(path string) ([]byte, error) {
 		return nil, errors.New(fmt.Sprintf("Failed to read HTTP response body. Error: %v", err))
 	}
 
-	if response.StatusCode != 200 {
+	if response.StatusCode < 200 || response.StatusCode > 299 {
 		return nil, handleError(response.StatusCode, string(body[:]))
 	}
 
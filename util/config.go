@@ -23,12 +23,11 @@ const (
 type Config map[string]string
 
 func LoadConfigFromHome(configFileName string) (Config, error) {
-	usr, err := user.Current()
+	configFilePath, err := HomePath(configFileName)
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("Failed to find home directory. Error: %v", err))
+		return nil, err
 	}
 
-	configFilePath := fmt.Sprintf("%v%c%v", usr.HomeDir, os.PathSeparator, configFileName)
 	config, err := readConfig(configFilePath)
 	if err != nil {
 		return nil, errors.New(fmt.Sprintf("Failed to load %v. Error: %v", configFilePath, err))
@@ -37,6 +36,16 @@ func LoadConfigFromHome(configFileName string) (Config, error) {
 	return config, nil
 }
 
+// HomePath returns the path of the file in the current user's home directory.
+func HomePath(fileName string) (string, error) {
+	usr, err := user.Current()
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("Failed to find home directory. Error: %v", err))
+	}
+
+	return fmt.Sprintf("%v%c%v", usr.HomeDir, os.PathSeparator, fileName), nil
+}
+
 func (config Config) GetCredentials() (string, string) {
 	return config["username"], config["apikey"]
 }
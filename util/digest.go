@@ -57,6 +57,7 @@ var (
 	ErrNilTransport      = errors.New("Transport is nil")
 	ErrBadChallenge      = errors.New("Challenge is bad")
 	ErrAlgNotImplemented = errors.New("Alg not implemented")
+	ErrBodyNotRewindable = errors.New("Request body can not be sent twice")
 )
 
 // Transport is an implementation of http.RoundTripper that takes care of http
@@ -251,6 +252,22 @@ func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 		return resp, err
 	}
 
+	// The first request consumed the body, so the authenticated request
+	// needs a fresh copy of it.
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			resp.Body.Close()
+			return nil, ErrBodyNotRewindable
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		req2.Body = body
+	}
+	resp.Body.Close()
+
 	// Form credentials based on the challenge.
 	cr := t.newCredentials(req2, c)
 	auth, err := cr.authorize()
@@ -0,0 +1,309 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"../model"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Expression is an arithmetic expression over metric names, such as
+// "CONNECTIONS / 20000 * 100". It supports numbers, metric names, + - * /,
+// unary minus and parentheses.
+type Expression struct {
+	text    string
+	root    node
+	metrics []string
+}
+
+type node interface {
+	eval(values map[string]float64) (float64, error)
+}
+
+type number float64
+
+type metricRef string
+
+type unary struct {
+	operand node
+}
+
+type binary struct {
+	op          byte
+	left, right node
+}
+
+func (n number) eval(values map[string]float64) (float64, error) {
+	return float64(n), nil
+}
+
+func (m metricRef) eval(values map[string]float64) (float64, error) {
+	value, ok := values[string(m)]
+	if !ok {
+		return 0, errors.New(fmt.Sprintf("No value for %v", string(m)))
+	}
+
+	return value, nil
+}
+
+func (u unary) eval(values map[string]float64) (float64, error) {
+	value, err := u.operand.eval(values)
+	return -value, err
+}
+
+func (b binary) eval(values map[string]float64) (float64, error) {
+	left, err := b.left.eval(values)
+	if err != nil {
+		return 0, err
+	}
+
+	right, err := b.right.eval(values)
+	if err != nil {
+		return 0, err
+	}
+
+	switch b.op {
+	case '+':
+		return left + right, nil
+	case '-':
+		return left - right, nil
+	case '*':
+		return left * right, nil
+	}
+
+	if right == 0 {
+		return 0, errors.New("Division by zero")
+	}
+
+	return left / right, nil
+}
+
+// ParseExpression parses the text of an expression.
+func ParseExpression(text string) (*Expression, error) {
+	p := &parser{text: text, metrics: make(map[string]bool)}
+	p.next()
+
+	root, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.token != "" {
+		return nil, p.errorf("unexpected %q", p.token)
+	}
+
+	expression := &Expression{text: text, root: root}
+	for name := range p.metrics {
+		expression.metrics = append(expression.metrics, name)
+	}
+	sort.Strings(expression.metrics)
+
+	return expression, nil
+}
+
+// MetricNames returns the sorted names of the metrics the expression uses.
+func (expression *Expression) MetricNames() []string {
+	return expression.metrics
+}
+
+// Evaluate returns the value of the expression given the value of each
+// metric it uses.
+func (expression *Expression) Evaluate(values map[string]float64) (float64, error) {
+	return expression.root.eval(values)
+}
+
+func (expression *Expression) String() string {
+	return expression.text
+}
+
+// AlignDataPoints returns the timestamps, in order, at which every metric has
+// a data point, along with the values of the metrics at each of them.
+func AlignDataPoints(metrics map[string][]model.DataPoint) ([]time.Time, []map[string]float64) {
+	counts := make(map[int64]int)
+	values := make(map[int64]map[string]float64)
+	for name, points := range metrics {
+		for _, point := range points {
+			key := point.Timestamp.Unix()
+			if values[key] == nil {
+				values[key] = make(map[string]float64)
+			}
+
+			if _, ok := values[key][name]; !ok {
+				counts[key]++
+			}
+			values[key][name] = point.Value
+		}
+	}
+
+	keys := make([]int64, 0, len(counts))
+	for key, count := range counts {
+		if count == len(metrics) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	timestamps := make([]time.Time, len(keys))
+	aligned := make([]map[string]float64, len(keys))
+	for i, key := range keys {
+		timestamps[i] = time.Unix(key, 0)
+		aligned[i] = values[key]
+	}
+
+	return timestamps, aligned
+}
+
+// parser is a recursive descent parser of the grammar
+//
+//	sum     = product { ("+" | "-") product }
+//	product = factor { ("*" | "/") factor }
+//	factor  = "-" factor | number | metric | "(" sum ")"
+type parser struct {
+	text    string
+	pos     int
+	token   string
+	metrics map[string]bool
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return errors.New(fmt.Sprintf("Error parsing expression %q at %v: %v", p.text, p.pos, fmt.Sprintf(format, args...)))
+}
+
+// next moves to the next token, which is "" at the end of the text.
+func (p *parser) next() {
+	for p.pos < len(p.text) && p.text[p.pos] == ' ' {
+		p.pos++
+	}
+
+	start := p.pos
+	if p.pos >= len(p.text) {
+		p.token = ""
+		return
+	}
+
+	c := p.text[p.pos]
+	switch {
+	case isDigit(c) || c == '.':
+		p.skip(func(c byte) bool { return isDigit(c) || c == '.' })
+
+		// An exponent, such as in 1e-6, is only part of the number if
+		// digits follow it.
+		exponent := p.pos
+		if exponent < len(p.text) && (p.text[exponent] == 'e' || p.text[exponent] == 'E') {
+			exponent++
+			if exponent < len(p.text) && (p.text[exponent] == '+' || p.text[exponent] == '-') {
+				exponent++
+			}
+			if exponent < len(p.text) && isDigit(p.text[exponent]) {
+				p.pos = exponent
+				p.skip(isDigit)
+			}
+		}
+	case isIdentifier(c):
+		p.skip(isIdentifier)
+	default:
+		p.pos++
+	}
+
+	p.token = p.text[start:p.pos]
+}
+
+// skip moves past the characters that match.
+func (p *parser) skip(match func(c byte) bool) {
+	for p.pos < len(p.text) && match(p.text[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *parser) parseSum() (node, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.token == "+" || p.token == "-" {
+		op := p.token[0]
+		p.next()
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = binary{op, left, right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseProduct() (node, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.token == "*" || p.token == "/" {
+		op := p.token[0]
+		p.next()
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = binary{op, left, right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseFactor() (node, error) {
+	token := p.token
+	switch {
+	case token == "":
+		return nil, p.errorf("unexpected end")
+	case token == "-":
+		p.next()
+		operand, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return unary{operand}, nil
+	case token == "(":
+		p.next()
+		inner, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.token != ")" {
+			return nil, p.errorf("missing )")
+		}
+		p.next()
+		return inner, nil
+	case isDigit(token[0]) || token[0] == '.':
+		value, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return nil, p.errorf("bad number %q", token)
+		}
+		p.next()
+		return number(value), nil
+	case isIdentifier(token[0]):
+		p.metrics[token] = true
+		p.next()
+		return metricRef(token), nil
+	}
+
+	return nil, p.errorf("unexpected %q", token)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isIdentifier returns true if the character can be part of a metric name.
+func isIdentifier(c byte) bool {
+	return c == '_' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || isDigit(c)
+}
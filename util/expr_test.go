@@ -0,0 +1,95 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"../model"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExpression(t *testing.T) {
+	values := map[string]float64{
+		"CONNECTIONS":                       2500,
+		"GLOBAL_LOCK_CURRENT_QUEUE_READERS": 1,
+		"GLOBAL_LOCK_CURRENT_QUEUE_WRITERS": 2,
+		"A":                                 3,
+		"B":                                 1,
+	}
+
+	tests := []struct {
+		text    string
+		value   float64
+		metrics []string
+	}{
+		{"CONNECTIONS / 20000 * 100", 12.5, []string{"CONNECTIONS"}},
+		{"GLOBAL_LOCK_CURRENT_QUEUE_READERS + GLOBAL_LOCK_CURRENT_QUEUE_WRITERS", 3, []string{"GLOBAL_LOCK_CURRENT_QUEUE_READERS", "GLOBAL_LOCK_CURRENT_QUEUE_WRITERS"}},
+		{"-(A - B) * 2 / .5", -8, []string{"A", "B"}},
+		{"A + B * 2", 5, []string{"A", "B"}},
+		{"A - B - 1", 1, []string{"A", "B"}},
+		{"1e3 * A", 3000, []string{"A"}},
+		{"A * 1e-6", 3e-6, []string{"A"}},
+		{"A * 2.5E+2", 750, []string{"A"}},
+		{"A*B", 3, []string{"A", "B"}},
+		{"B + B", 2, []string{"B"}},
+	}
+
+	for _, test := range tests {
+		expression, err := ParseExpression(test.text)
+		if err != nil {
+			t.Errorf("%v: %v", test.text, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(expression.MetricNames(), test.metrics) {
+			t.Errorf("%v: metrics %v, expected %v", test.text, expression.MetricNames(), test.metrics)
+		}
+
+		value, err := expression.Evaluate(values)
+		if err != nil || math.Abs(value-test.value) > 1e-12 {
+			t.Errorf("%v = %v (%v), expected %v", test.text, value, err, test.value)
+		}
+	}
+}
+
+func TestExpressionErrors(t *testing.T) {
+	for _, text := range []string{"", "A +", "(A", "A)", "A..B", "1.2.3", "2A", "A $ B", "CONNECTIONS é", "1e"} {
+		if expression, err := ParseExpression(text); err == nil {
+			t.Errorf("%q: expected an error, parsed %v using %v", text, expression, expression.MetricNames())
+		}
+	}
+
+	expression, err := ParseExpression("A / (B - 1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := expression.Evaluate(map[string]float64{"A": 1, "B": 1}); err == nil {
+		t.Errorf("expected division by zero")
+	}
+	if _, err := expression.Evaluate(map[string]float64{"A": 1}); err == nil {
+		t.Errorf("expected a missing value for B")
+	}
+}
+
+func TestAlignDataPoints(t *testing.T) {
+	at := func(minutes int, value float64) model.DataPoint {
+		return model.DataPoint{Timestamp: origin.Add(time.Duration(minutes) * time.Minute), Value: value}
+	}
+
+	timestamps, values := AlignDataPoints(map[string][]model.DataPoint{
+		"A": {at(0, 1), at(1, 2), at(2, 3)},
+		"B": {at(1, 20), at(2, 30), at(3, 40)},
+	})
+
+	expected := []time.Time{origin.Add(time.Minute), origin.Add(2 * time.Minute)}
+	if len(timestamps) != len(expected) || !timestamps[0].Equal(expected[0]) || !timestamps[1].Equal(expected[1]) {
+		t.Fatalf("timestamps %v, expected %v", timestamps, expected)
+	}
+	if !reflect.DeepEqual(values, []map[string]float64{{"A": 2, "B": 20}, {"A": 3, "B": 30}}) {
+		t.Errorf("values %v", values)
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a set of minutes described like a crontab entry: the five
+// fields minute, hour, day of month, month and day of week, separated by
+// spaces. A field is * or a comma separated list of values and ranges, each
+// optionally followed by /step, such as "0-59/15" or "mon-fri". As in cron, if
+// both the day of month and the day of week are restricted a time matches if
+// either of them does.
+type Schedule struct {
+	text       string
+	minutes    []bool
+	hours      []bool
+	days       []bool
+	months     []bool
+	weekdays   []bool
+	anyDay     bool
+	anyWeekday bool
+}
+
+var monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ParseSchedule parses the crontab style text of a schedule.
+func ParseSchedule(text string) (*Schedule, error) {
+	fields := strings.Fields(text)
+	if len(fields) != 5 {
+		return nil, errors.New(fmt.Sprintf("Error parsing schedule %q: expected 5 fields, found %v", text, len(fields)))
+	}
+
+	schedule := &Schedule{
+		text:       text,
+		anyDay:     fields[2] == "*",
+		anyWeekday: fields[4] == "*",
+	}
+
+	var err error
+	if schedule.minutes, err = parseScheduleField(fields[0], 0, 59, nil); err != nil {
+		return nil, errors.New(fmt.Sprintf("Error parsing minutes of schedule %q: %v", text, err))
+	}
+
+	if schedule.hours, err = parseScheduleField(fields[1], 0, 23, nil); err != nil {
+		return nil, errors.New(fmt.Sprintf("Error parsing hours of schedule %q: %v", text, err))
+	}
+
+	if schedule.days, err = parseScheduleField(fields[2], 1, 31, nil); err != nil {
+		return nil, errors.New(fmt.Sprintf("Error parsing days of schedule %q: %v", text, err))
+	}
+
+	if schedule.months, err = parseScheduleField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, errors.New(fmt.Sprintf("Error parsing months of schedule %q: %v", text, err))
+	}
+
+	// Sunday is both 0 and 7, as in cron.
+	if schedule.weekdays, err = parseScheduleField(fields[4], 0, 7, weekdayNames); err != nil {
+		return nil, errors.New(fmt.Sprintf("Error parsing weekdays of schedule %q: %v", text, err))
+	}
+	schedule.weekdays[0] = schedule.weekdays[0] || schedule.weekdays[7]
+
+	return schedule, nil
+}
+
+// Matches returns true if the minute of the time is in the schedule. The
+// fields are compared in the time's location.
+func (schedule *Schedule) Matches(t time.Time) bool {
+	if !schedule.minutes[t.Minute()] || !schedule.hours[t.Hour()] || !schedule.months[t.Month()] {
+		return false
+	}
+
+	day := schedule.days[t.Day()]
+	weekday := schedule.weekdays[t.Weekday()]
+	switch {
+	case schedule.anyDay && schedule.anyWeekday:
+		return true
+	case schedule.anyDay:
+		return weekday
+	case schedule.anyWeekday:
+		return day
+	}
+
+	return day || weekday
+}
+
+func (schedule *Schedule) String() string {
+	return schedule.text
+}
+
+// parseScheduleField returns which of the values from 0 to max the field
+// selects. Values may be given by name, where names[0] is min.
+func parseScheduleField(field string, min int, max int, names []string) ([]bool, error) {
+	selected := make([]bool, max+1)
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return nil, errors.New(fmt.Sprintf("bad step %q", part[i+1:]))
+			}
+			part = part[:i]
+		}
+
+		low, high := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if low, err = parseScheduleValue(bounds[0], min, max, names); err != nil {
+				return nil, err
+			}
+
+			high = low
+			if len(bounds) == 2 {
+				if high, err = parseScheduleValue(bounds[1], min, max, names); err != nil {
+					return nil, err
+				}
+			}
+
+			if high < low {
+				return nil, errors.New(fmt.Sprintf("bad range %q", part))
+			}
+		}
+
+		for value := low; value <= high; value += step {
+			selected[value] = true
+		}
+	}
+
+	return selected, nil
+}
+
+func parseScheduleValue(text string, min int, max int, names []string) (int, error) {
+	for i, name := range names {
+		if strings.EqualFold(text, name) {
+			return min + i, nil
+		}
+	}
+
+	value, err := strconv.Atoi(text)
+	if err != nil || value < min || value > max {
+		return 0, errors.New(fmt.Sprintf("bad value %q, expected %v to %v", text, min, max))
+	}
+
+	return value, nil
+}
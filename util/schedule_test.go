@@ -0,0 +1,80 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleMatches(t *testing.T) {
+	// 2015-06-01 is a Monday.
+	at := func(day int, hour int, minute int) time.Time {
+		return time.Date(2015, 6, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		schedule string
+		time     time.Time
+		matches  bool
+	}{
+		{"* * * * *", at(1, 0, 0), true},
+		{"0-59/15 * * * *", at(1, 3, 30), true},
+		{"0-59/15 * * * *", at(1, 3, 31), false},
+		{"*/20 9 * * *", at(1, 9, 40), true},
+		{"* 22-23,0-5 * * mon-fri", at(1, 23, 10), true},
+		{"* 22-23,0-5 * * mon-fri", at(1, 6, 0), false},
+		{"* 22-23,0-5 * * mon-fri", at(6, 23, 10), false},
+		{"* * * * sat,sun", at(6, 12, 0), true},
+		{"* * * * sat,sun", at(7, 12, 0), true},
+		{"* * * * 7", at(7, 12, 0), true},
+		{"* * * * 0", at(7, 12, 0), true},
+		{"* * * * SUN", at(8, 12, 0), false},
+		{"* * * jun *", at(1, 0, 0), true},
+		{"* * * jan-may,jul-dec *", at(1, 0, 0), false},
+		{"* * 15 * *", at(15, 0, 0), true},
+		{"* * 15 * *", at(16, 0, 0), false},
+		// With both the day of month and the day of week restricted either
+		// of them matches, as in cron.
+		{"* * 15 * mon", at(15, 0, 0), true},
+		{"* * 15 * mon", at(8, 0, 0), true},
+		{"* * 15 * mon", at(9, 0, 0), false},
+	}
+
+	for _, test := range tests {
+		schedule, err := ParseSchedule(test.schedule)
+		if err != nil {
+			t.Errorf("%v: %v", test.schedule, err)
+			continue
+		}
+
+		if matches := schedule.Matches(test.time); matches != test.matches {
+			t.Errorf("%q matches %v: %v, expected %v", test.schedule, test.time, matches, test.matches)
+		}
+	}
+}
+
+func TestScheduleMatchesInLocation(t *testing.T) {
+	schedule, err := ParseSchedule("* 9-17 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	utc := time.Date(2015, 6, 1, 8, 0, 0, 0, time.UTC)
+	if schedule.Matches(utc) {
+		t.Errorf("%v matches %v", schedule, utc)
+	}
+	if local := utc.In(time.FixedZone("CEST", 2*60*60)); !schedule.Matches(local) {
+		t.Errorf("%v doesn't match %v", schedule, local)
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, text := range []string{"", "* * * *", "* * * * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "* * * foo *", "a * * * *"} {
+		if schedule, err := ParseSchedule(text); err == nil {
+			t.Errorf("%q: expected an error, parsed %v", text, schedule)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// StateStore keeps small JSON documents between runs of the plugin. Every key
+// has its own file so checks running at the same time don't overwrite each
+// other, and files are replaced atomically so a crash never leaves a partial
+// document behind.
+type StateStore struct {
+	dir string
+}
+
+func NewStateStore(dir string) *StateStore {
+	return &StateStore{dir: dir}
+}
+
+// Load reads the document stored under the key into state. It returns false
+// if there is no document, or if it can't be read, in which case the caller
+// should carry on as if this were the first run.
+func (store *StateStore) Load(key string, state interface{}) bool {
+	buffer, err := ioutil.ReadFile(store.path(key))
+	if err != nil {
+		return false
+	}
+
+	return json.Unmarshal(buffer, state) == nil
+}
+
+// Save stores the document under the key.
+func (store *StateStore) Save(key string, state interface{}) error {
+	buffer, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(store.dir, 0700); err != nil {
+		return errors.New(fmt.Sprintf("Failed to create state directory %v. Error: %v", store.dir, err))
+	}
+
+	tmp, err := ioutil.TempFile(store.dir, ".tmp")
+	if err != nil {
+		return errors.New(fmt.Sprintf("Failed to write state. Error: %v", err))
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(buffer); err != nil {
+		tmp.Close()
+		return errors.New(fmt.Sprintf("Failed to write state. Error: %v", err))
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.New(fmt.Sprintf("Failed to write state. Error: %v", err))
+	}
+
+	if err := tmp.Close(); err != nil {
+		return errors.New(fmt.Sprintf("Failed to write state. Error: %v", err))
+	}
+
+	if err := os.Rename(tmp.Name(), store.path(key)); err != nil {
+		return errors.New(fmt.Sprintf("Failed to write state. Error: %v", err))
+	}
+
+	return nil
+}
+
+func (store *StateStore) path(key string) string {
+	return filepath.Join(store.dir, fmt.Sprintf("%x.json", sha1.Sum([]byte(key))))
+}
@@ -0,0 +1,203 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"../model"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	FitLinear   = "linear"
+	FitTheilSen = "theilsen"
+
+	day = 24 * time.Hour
+)
+
+// Fit is a straight line through a series of data points. X is measured in
+// days since the Origin.
+type Fit struct {
+	Origin    time.Time
+	Slope     float64
+	Intercept float64
+	R2        float64
+}
+
+// At returns the value of the line at the given time.
+func (fit Fit) At(t time.Time) float64 {
+	return fit.Intercept + fit.Slope*days(fit.Origin, t)
+}
+
+// DaysUntil returns the days from the given time until the line reaches the
+// value, negative if it reached it before. It returns false if the line is
+// flat. The days are computed without time.Duration, which only spans about
+// 292 years.
+func (fit Fit) DaysUntil(value float64, t time.Time) (float64, bool) {
+	if fit.Slope == 0 || math.IsNaN(fit.Slope) {
+		return 0, false
+	}
+
+	x := (value - fit.Intercept) / fit.Slope
+	return x - days(fit.Origin, t), true
+}
+
+// LinearFit fits a least squares line to the data points.
+func LinearFit(points []model.DataPoint) Fit {
+	fit := Fit{Origin: points[0].Timestamp}
+
+	var sumX, sumY float64
+	for _, point := range points {
+		sumX += days(fit.Origin, point.Timestamp)
+		sumY += point.Value
+	}
+	meanX := sumX / float64(len(points))
+	meanY := sumY / float64(len(points))
+
+	var sxy, sxx float64
+	for _, point := range points {
+		dx := days(fit.Origin, point.Timestamp) - meanX
+		sxy += dx * (point.Value - meanY)
+		sxx += dx * dx
+	}
+
+	if sxx != 0 {
+		fit.Slope = sxy / sxx
+	}
+	fit.Intercept = meanY - fit.Slope*meanX
+	fit.R2 = rSquared(fit, points)
+	return fit
+}
+
+// TheilSenFit fits a line whose slope is the median of the slopes between
+// every pair of data points, which is robust against outliers such as a
+// large collection being dropped and recreated.
+func TheilSenFit(points []model.DataPoint) Fit {
+	fit := Fit{Origin: points[0].Timestamp}
+
+	slopes := make([]float64, 0, len(points)*(len(points)-1)/2)
+	for i := 0; i < len(points); i++ {
+		for j := i + 1; j < len(points); j++ {
+			dx := days(points[i].Timestamp, points[j].Timestamp)
+			if dx != 0 {
+				slopes = append(slopes, (points[j].Value-points[i].Value)/dx)
+			}
+		}
+	}
+	// Without two distinct timestamps there's no slope, so the line is flat.
+	if len(slopes) > 0 {
+		fit.Slope = Median(slopes)
+	}
+
+	intercepts := make([]float64, len(points))
+	for i, point := range points {
+		intercepts[i] = point.Value - fit.Slope*days(fit.Origin, point.Timestamp)
+	}
+	fit.Intercept = Median(intercepts)
+	fit.R2 = rSquared(fit, points)
+	return fit
+}
+
+// Mean returns the mean of the values, or NaN if there are none.
+func Mean(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+
+	return sum / float64(len(values))
+}
+
+// StdDev returns the population standard deviation of the values, or NaN if
+// there are none.
+func StdDev(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+
+	mean := Mean(values)
+	var sum float64
+	for _, value := range values {
+		sum += (value - mean) * (value - mean)
+	}
+
+	return math.Sqrt(sum / float64(len(values)))
+}
+
+// Max returns the largest of the values, or NaN if there are none.
+func Max(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+
+	max := values[0]
+	for _, value := range values[1:] {
+		max = math.Max(max, value)
+	}
+
+	return max
+}
+
+// Values returns the values of the data points.
+func Values(points []model.DataPoint) []float64 {
+	values := make([]float64, len(points))
+	for i, point := range points {
+		values[i] = point.Value
+	}
+
+	return values
+}
+
+// Median returns the median of the values, or NaN if there are none. The
+// values are not modified.
+func Median(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[middle]
+	}
+
+	return (sorted[middle-1] + sorted[middle]) / 2
+}
+
+// rSquared returns the coefficient of determination of the line for the
+// data points. It is 1 for a perfect fit and may be negative for a line that
+// fits worse than the mean.
+func rSquared(fit Fit, points []model.DataPoint) float64 {
+	var sum float64
+	for _, point := range points {
+		sum += point.Value
+	}
+	mean := sum / float64(len(points))
+
+	var ssRes, ssTot float64
+	for _, point := range points {
+		residual := point.Value - fit.At(point.Timestamp)
+		ssRes += residual * residual
+		ssTot += (point.Value - mean) * (point.Value - mean)
+	}
+
+	if ssTot == 0 {
+		return 1
+	}
+
+	return 1 - ssRes/ssTot
+}
+
+func days(from time.Time, to time.Time) float64 {
+	return float64(to.Sub(from)) / float64(day)
+}
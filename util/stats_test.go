@@ -0,0 +1,100 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"../model"
+	"math"
+	"testing"
+	"time"
+)
+
+var origin = time.Date(2015, 6, 1, 0, 0, 0, 0, time.UTC)
+
+// series returns data points an hour apart with the values.
+func series(values ...float64) []model.DataPoint {
+	points := make([]model.DataPoint, len(values))
+	for i, value := range values {
+		points[i] = model.DataPoint{Timestamp: origin.Add(time.Duration(i) * time.Hour), Value: value}
+	}
+
+	return points
+}
+
+func TestFits(t *testing.T) {
+	tests := []struct {
+		name   string
+		fitter func([]model.DataPoint) Fit
+		points []model.DataPoint
+		slope  float64
+	}{
+		{"linear", LinearFit, series(10, 11, 12, 13), 24},
+		{"linear flat", LinearFit, series(5, 5, 5), 0},
+		{"theilsen", TheilSenFit, series(10, 11, 12, 13), 24},
+		{"theilsen outlier", TheilSenFit, series(10, 11, 500, 13, 14), 24},
+		{"theilsen same timestamps", TheilSenFit, []model.DataPoint{{Timestamp: origin, Value: 1}, {Timestamp: origin, Value: 2}}, 0},
+	}
+
+	for _, test := range tests {
+		fit := test.fitter(test.points)
+		if math.Abs(fit.Slope-test.slope) > 1e-9 {
+			t.Errorf("%v: slope %v, expected %v", test.name, fit.Slope, test.slope)
+		}
+		if math.IsNaN(fit.Intercept) || math.IsNaN(fit.R2) {
+			t.Errorf("%v: intercept %v, R² %v", test.name, fit.Intercept, fit.R2)
+		}
+	}
+}
+
+func TestDaysUntil(t *testing.T) {
+	tests := []struct {
+		fit   Fit
+		value float64
+		days  float64
+		ok    bool
+	}{
+		{Fit{Origin: origin, Slope: 10, Intercept: 0}, 100, 10, true},
+		{Fit{Origin: origin, Slope: -10, Intercept: 100}, 0, 10, true},
+		{Fit{Origin: origin, Slope: 10, Intercept: 200}, 100, -10, true},
+		{Fit{Origin: origin, Slope: 1e-6, Intercept: 0}, 1, 1e6, true},
+		{Fit{Origin: origin, Slope: 0, Intercept: 0}, 1, 0, false},
+		{Fit{Origin: origin, Slope: math.NaN(), Intercept: 0}, 1, 0, false},
+	}
+
+	for _, test := range tests {
+		days, ok := test.fit.DaysUntil(test.value, origin)
+		if ok != test.ok || (ok && math.Abs(days-test.days) > 1e-6) {
+			t.Errorf("%+v reaches %v in %v days (%v), expected %v (%v)", test.fit, test.value, days, ok, test.days, test.ok)
+		}
+	}
+}
+
+func TestMedianMeanStdDevMax(t *testing.T) {
+	values := []float64{4, 1, 3, 2}
+	if median := Median(values); median != 2.5 {
+		t.Errorf("median %v, expected 2.5", median)
+	}
+	if values[0] != 4 {
+		t.Errorf("median modified the values: %v", values)
+	}
+	if median := Median([]float64{3, 1, 2}); median != 2 {
+		t.Errorf("median %v, expected 2", median)
+	}
+	if mean := Mean(values); mean != 2.5 {
+		t.Errorf("mean %v, expected 2.5", mean)
+	}
+	if stdDev := StdDev([]float64{2, 4, 4, 4, 5, 5, 7, 9}); stdDev != 2 {
+		t.Errorf("standard deviation %v, expected 2", stdDev)
+	}
+	if max := Max(values); max != 4 {
+		t.Errorf("max %v, expected 4", max)
+	}
+
+	for _, f := range []func([]float64) float64{Median, Mean, StdDev, Max} {
+		if value := f(nil); !math.IsNaN(value) {
+			t.Errorf("%v of no values, expected NaN", value)
+		}
+	}
+}
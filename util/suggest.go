@@ -0,0 +1,57 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"strings"
+)
+
+// Suggest returns the candidate closest to name by case insensitive edit
+// distance, or "" when no candidate is close enough to be a likely typo.
+func Suggest(name string, candidates []string) string {
+	name = strings.ToUpper(name)
+	best := ""
+	bestDistance := len(name)/3 + 1
+	for _, candidate := range candidates {
+		distance := levenshtein(name, strings.ToUpper(candidate))
+		if distance < bestDistance || (distance == bestDistance && best != "" && candidate < best) {
+			best = candidate
+			bestDistance = distance
+		}
+	}
+
+	return best
+}
+
+func levenshtein(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = minInt(minInt(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,75 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a MongoDB version such as "3.0.4". Suffixes like "-rc0" or
+// "-ent" are ignored when versions are compared.
+type Version struct {
+	text    string
+	numbers []int
+}
+
+// ParseVersion parses the text of a version, which is at least a major and
+// minor version separated by a dot.
+func ParseVersion(text string) (Version, error) {
+	release := strings.TrimSpace(text)
+	if i := strings.IndexAny(release, "-+ "); i >= 0 {
+		release = release[:i]
+	}
+
+	fields := strings.Split(release, ".")
+	if len(fields) < 2 {
+		return Version{}, errors.New(fmt.Sprintf("Error parsing version %q: expected at least major.minor", text))
+	}
+
+	numbers := make([]int, len(fields))
+	for i, field := range fields {
+		number, err := strconv.Atoi(field)
+		if err != nil || number < 0 {
+			return Version{}, errors.New(fmt.Sprintf("Error parsing version %q: %q is not a number", text, field))
+		}
+		numbers[i] = number
+	}
+
+	return Version{text: strings.TrimSpace(text), numbers: numbers}, nil
+}
+
+// Series returns the major and minor version, such as "3.0".
+func (version Version) Series() string {
+	return fmt.Sprintf("%v.%v", version.numbers[0], version.numbers[1])
+}
+
+// Compare returns -1, 0 or 1 if the version is older than, the same as or
+// newer than the other. Missing numbers count as 0, so "3.0" is "3.0.0".
+func (version Version) Compare(other Version) int {
+	for i := 0; i < len(version.numbers) || i < len(other.numbers); i++ {
+		a, b := 0, 0
+		if i < len(version.numbers) {
+			a = version.numbers[i]
+		}
+		if i < len(other.numbers) {
+			b = other.numbers[i]
+		}
+
+		if a < b {
+			return -1
+		} else if a > b {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func (version Version) String() string {
+	return version.text
+}
@@ -0,0 +1,77 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"testing"
+)
+
+func TestVersionCompare(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		compare int
+	}{
+		{"3.0.4", "3.0.4", 0},
+		{"3.0.4", "3.0.10", -1},
+		{"3.2.0", "3.0.10", 1},
+		{"2.6.9", "3.0.0", -1},
+		{"3.0", "3.0.0", 0},
+		{"3.0", "3.0.1", -1},
+		{"3.0.4-rc0", "3.0.4", 0},
+		{"3.0.4-ent", "3.0.3", 1},
+		{" 3.0.4 ", "3.0.4", 0},
+	}
+
+	for _, test := range tests {
+		a, err := ParseVersion(test.a)
+		if err != nil {
+			t.Fatalf("%v: %v", test.a, err)
+		}
+		b, err := ParseVersion(test.b)
+		if err != nil {
+			t.Fatalf("%v: %v", test.b, err)
+		}
+
+		if compare := a.Compare(b); compare != test.compare {
+			t.Errorf("%v compared with %v is %v, expected %v", test.a, test.b, compare, test.compare)
+		}
+		if compare := b.Compare(a); compare != -test.compare {
+			t.Errorf("%v compared with %v is %v, expected %v", test.b, test.a, compare, -test.compare)
+		}
+	}
+}
+
+func TestVersionSeries(t *testing.T) {
+	tests := map[string]string{
+		"3.0.4":      "3.0",
+		"2.6":        "2.6",
+		"3.2.0-rc2":  "3.2",
+		"10.12.1.5":  "10.12",
+		"3.04.1-ent": "3.4",
+	}
+
+	for text, series := range tests {
+		version, err := ParseVersion(text)
+		if err != nil {
+			t.Errorf("%v: %v", text, err)
+			continue
+		}
+
+		if version.Series() != series {
+			t.Errorf("%v is of series %v, expected %v", text, version.Series(), series)
+		}
+		if version.String() != text {
+			t.Errorf("%v is shown as %v", text, version)
+		}
+	}
+}
+
+func TestParseVersionErrors(t *testing.T) {
+	for _, text := range []string{"", "3", "x", "3.x", "3..1", "-3.0", "3.-1", "v3.0.4"} {
+		if version, err := ParseVersion(text); err == nil {
+			t.Errorf("%q: expected an error, parsed %v", text, version)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"./model"
+	"./util"
+	"flag"
+	"fmt"
+	"github.com/fractalcat/nagiosplugin"
+	"os"
+	"sort"
+	"strings"
+)
+
+var minVersion string
+var versionSeries string
+
+// doVersionCheck checks the MongoDB version of every host of the group, or
+// only of --replica-set, against the policy: a host older than --min-version
+// or of a series not in --series is CRITICAL, and a member of a replica set
+// or sharded cluster that doesn't run the same version as most of the others
+// is a WARNING. The shards, config servers and mongos of a sharded cluster are
+// compared with each other. Hosts whose version MMS/Ops Manager doesn't know
+// yet are skipped.
+func doVersionCheck(check *nagiosplugin.Check, api *util.MMSAPI, host *model.Host) {
+	var minimum *util.Version
+	if minVersion != "" {
+		version, err := util.ParseVersion(minVersion)
+		if err != nil {
+			check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+			return
+		}
+		minimum = &version
+	}
+
+	allowed := make(map[string]bool)
+	for _, series := range strings.Split(versionSeries, ",") {
+		if series = strings.TrimSpace(series); series != "" {
+			allowed[series] = true
+		}
+	}
+
+	hosts, err := api.GetAllHosts(groupId)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+
+	clusters, err := api.GetClusters(groupId)
+	if err != nil {
+		check.AddResultf(nagiosplugin.UNKNOWN, "%v", err)
+		return
+	}
+	deployments := deploymentNames(clusters)
+
+	var members []model.Host
+	texts := make(map[string]string)
+	versions := make(map[string]util.Version)
+	status := nagiosplugin.OK
+	problems := make(map[string][]string)
+	statuses := make(map[string]nagiosplugin.Status)
+	report := func(member model.Host, hostStatus nagiosplugin.Status, problem string) {
+		name := member.Name()
+		problems[name] = append(problems[name], problem)
+		if statusSeverity(hostStatus) > statusSeverity(statuses[name]) {
+			statuses[name] = hostStatus
+		}
+		if statusSeverity(hostStatus) > statusSeverity(status) {
+			status = hostStatus
+		}
+	}
+
+	for _, member := range hosts {
+		if member.Version == "" || (replicaSet != "" && member.ReplicaSetName != replicaSet) {
+			continue
+		}
+		members = append(members, member)
+		texts[member.Name()] = member.Version
+
+		version, err := util.ParseVersion(member.Version)
+		if err != nil {
+			report(member, nagiosplugin.WARNING, "which isn't a version")
+			continue
+		}
+		versions[member.Name()] = version
+
+		if minimum != nil && version.Compare(*minimum) < 0 {
+			report(member, nagiosplugin.CRITICAL, fmt.Sprintf("older than %v", minimum))
+		}
+		if len(allowed) > 0 && !allowed[version.Series()] {
+			report(member, nagiosplugin.CRITICAL, fmt.Sprintf("series %v is not allowed", version.Series()))
+		}
+	}
+
+	grouped := make(map[string][]model.Host)
+	var names []string
+	for _, member := range members {
+		name, ok := deployments[member.ClusterId]
+		if !ok {
+			name = member.ReplicaSetName
+		}
+		if _, ok := versions[member.Name()]; !ok || name == "" {
+			continue
+		}
+
+		if _, ok := grouped[name]; !ok {
+			names = append(names, name)
+		}
+		grouped[name] = append(grouped[name], member)
+	}
+
+	for _, name := range names {
+		common, mixed := commonVersion(grouped[name], versions)
+		if !mixed {
+			continue
+		}
+
+		for _, member := range grouped[name] {
+			if versions[member.Name()].Compare(common) != 0 {
+				report(member, nagiosplugin.WARNING, fmt.Sprintf("not on %v like the rest of %v", common, name))
+			}
+		}
+	}
+
+	hostNames := make([]string, 0, len(problems))
+	for name := range problems {
+		hostNames = append(hostNames, name)
+	}
+	sort.Strings(hostNames)
+
+	lines := make([]string, len(hostNames))
+	for i, name := range hostNames {
+		lines[i] = fmt.Sprintf("%v: %v runs %v, %v", statuses[name], name, texts[name], strings.Join(problems[name], ", "))
+	}
+
+	check.AddPerfDatum("hosts", "", float64(len(members)))
+	check.AddPerfDatum("noncompliant_hosts", "", float64(len(lines)))
+	if len(lines) == 0 {
+		check.AddResultf(nagiosplugin.OK, "All %v hosts comply with the version policy", len(members))
+		return
+	}
+
+	check.AddResultf(status, "%v of %v hosts don't comply with the version policy\n%v", len(lines), len(members), strings.Join(lines, "\n"))
+}
+
+// commonVersion returns the version most of the hosts run, the newest one if
+// there's a tie, and whether the hosts run different versions.
+func commonVersion(hosts []model.Host, versions map[string]util.Version) (util.Version, bool) {
+	counts := make(map[string]int)
+	var common util.Version
+	for _, host := range hosts {
+		version := versions[host.Name()]
+		counts[version.String()]++
+
+		count, best := counts[version.String()], counts[common.String()]
+		if common.String() == "" || count > best || (count == best && version.Compare(common) > 0) {
+			common = version
+		}
+	}
+
+	return common, len(counts) > 1
+}
+
+// deploymentNames maps the ID of every replica set and sharded cluster to
+// the deployment whose hosts must run the same version: the sharded cluster
+// for itself and its shards and config servers, which have its name, and the
+// replica set otherwise.
+func deploymentNames(clusters []model.Cluster) map[string]string {
+	names := make(map[string]string)
+	for _, cluster := range clusters {
+		if cluster.ClusterName != "" {
+			names[cluster.Id] = "cluster " + cluster.ClusterName
+		} else {
+			names[cluster.Id] = cluster.ReplicaSetName
+		}
+	}
+
+	return names
+}
+
+const (
+	minVersionUsage    = "the oldest version hosts may run, e.g. 3.0.4"
+	versionSeriesUsage = "comma separated release series hosts may run, e.g. 2.6,3.0"
+)
+
+func setupVersionFlags() {
+	flag.StringVar(&minVersion, "min-version", "", minVersionUsage)
+	flag.StringVar(&versionSeries, "series", "", versionSeriesUsage)
+}
+
+func versionUsage() {
+	fmt.Fprintf(os.Stdout, "\n     version mode: CRITICAL for hosts older than --min-version or not of a --series, WARNING for members of a replica set or sharded cluster not on the version of the others\n")
+	fmt.Fprintf(os.Stdout, "     --min-version %v\n", minVersionUsage)
+	fmt.Fprintf(os.Stdout, "     --series %v\n", versionSeriesUsage)
+}
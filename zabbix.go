@@ -0,0 +1,231 @@
+// Copyright 2015 MongoDB, Inc. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"./model"
+	"./util"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	DiscoverHosts      = "hosts"
+	DiscoverDatabases  = "databases"
+	DiscoverPartitions = "partitions"
+)
+
+var zabbixGroupId string
+var zabbixHostname string
+var zabbixMetricName string
+var zabbixDBName string
+var zabbixPartition string
+var zabbixDiscover string
+var zabbixServer string
+var zabbixTimeout int
+var zabbixMaxAge int
+
+// runZabbix either prints Zabbix low-level discovery JSON for the group, or
+// the bare value of a single metric so it can be used as a UserParameter.
+func runZabbix(args []string) {
+	flags := setupZabbixFlags(args)
+	if zabbixGroupId == "" || (zabbixDiscover == "" && (zabbixHostname == "" || zabbixMetricName == "")) {
+		flags.Usage()
+		os.Exit(2)
+		return
+	}
+
+	api, err := newAPI(zabbixServer, zabbixTimeout)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	if zabbixDiscover != "" {
+		err = doZabbixDiscovery(api)
+	} else {
+		err = doZabbixValue(api)
+	}
+
+	if err != nil {
+		exitWithError(err)
+	}
+}
+
+func doZabbixValue(api *util.MMSAPI) error {
+	host, err := api.GetHostByName(zabbixGroupId, zabbixHostname)
+	if err != nil {
+		return err
+	}
+
+	if err := validateMetric(api, zabbixGroupId, host.Id, zabbixMetricName, zabbixDBName, zabbixPartition); err != nil {
+		return err
+	}
+
+	metric, err := getMetric(api, zabbixGroupId, host.Id, zabbixMetricName, zabbixDBName, zabbixPartition)
+	if err != nil {
+		return err
+	}
+
+	if len(metric.DataPoints) == 0 {
+		return errors.New(fmt.Sprintf("No data points found for %v", zabbixMetricName))
+	}
+
+	lastDataPoint := metric.DataPoints[len(metric.DataPoints)-1]
+	age := time.Since(lastDataPoint.Timestamp)
+	if int(age.Seconds()) > zabbixMaxAge {
+		return errors.New(fmt.Sprintf("Last data point for %v is %v seconds old.", zabbixMetricName, int(age.Seconds())))
+	}
+
+	fmt.Fprintf(os.Stdout, "%v\n", strconv.FormatFloat(lastDataPoint.Value, 'f', -1, 64))
+	return nil
+}
+
+func doZabbixDiscovery(api *util.MMSAPI) error {
+	var hosts []model.Host
+	if zabbixHostname == "" {
+		var err error
+		if hosts, err = api.GetAllHosts(zabbixGroupId); err != nil {
+			return err
+		}
+	} else {
+		host, err := api.GetHostByName(zabbixGroupId, zabbixHostname)
+		if err != nil {
+			return err
+		}
+		hosts = []model.Host{*host}
+	}
+
+	sort.SliceStable(hosts, func(i, j int) bool {
+		return hosts[i].Name() < hosts[j].Name()
+	})
+
+	data := make([]map[string]string, 0, len(hosts))
+	for _, host := range hosts {
+		macros := map[string]string{
+			"{#HOSTNAME}": host.Hostname,
+			"{#PORT}":     strconv.Itoa(host.Port),
+			"{#RSNAME}":   host.ReplicaSetName,
+		}
+
+		switch zabbixDiscover {
+		case DiscoverHosts:
+			data = append(data, macros)
+		case DiscoverDatabases:
+			databases, err := api.GetHostDatabases(zabbixGroupId, host.Id)
+			if err != nil {
+				return err
+			}
+
+			for _, database := range databases {
+				data = append(data, withMacro(macros, "{#DBNAME}", database.DatabaseName))
+			}
+		case DiscoverPartitions:
+			disks, err := api.GetHostDisks(zabbixGroupId, host.Id)
+			if err != nil {
+				return err
+			}
+
+			for _, disk := range disks {
+				data = append(data, withMacro(macros, "{#PARTITION}", disk.PartitionName))
+			}
+		default:
+			return errors.New(fmt.Sprintf("Unknown discovery %v", zabbixDiscover))
+		}
+	}
+
+	output, err := json.Marshal(map[string]interface{}{"data": data})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stdout, "%s\n", output)
+	return nil
+}
+
+// withMacro copies the host's macros and adds one more.
+func withMacro(macros map[string]string, name string, value string) map[string]string {
+	ret := make(map[string]string, len(macros)+1)
+	for k, v := range macros {
+		ret[k] = v
+	}
+
+	ret[name] = value
+	return ret
+}
+
+func setupZabbixFlags(args []string) *flag.FlagSet {
+	const (
+		groupIdDefault   = ""
+		groupIdUsage     = "The MMS/Ops Manager group ID that contains the server"
+		hostnameDefault  = ""
+		hostnameUsage    = "hostname:port of the mongod/s to query"
+		metricDefault    = ""
+		metricUsage      = "metric to print the last value of"
+		dbNameDefault    = ""
+		dbNameUsage      = "database name for DB_ metrics"
+		partitionDefault = ""
+		partitionUsage   = "disk partition name for DISK_PARTITION_ metrics"
+		discoverDefault  = ""
+		discoverUsage    = "print low-level discovery JSON for hosts, databases or partitions"
+		maxAgeDefault    = 360
+		maxAgeUsage      = "the maximum number of seconds old a metric before it is considerd stale"
+		serverDefault    = "https://mms.mongodb.com"
+		serverUsage      = "hostname and port of the MMS/Ops Manager service"
+		timeoutDefault   = 10
+		timeoutUsage     = "connection timeout connecting MMS/Ops Manager service"
+	)
+
+	flags := flag.NewFlagSet("zabbix", flag.ExitOnError)
+
+	flags.StringVar(&zabbixGroupId, "groupid", groupIdDefault, groupIdUsage)
+	flags.StringVar(&zabbixGroupId, "g", groupIdDefault, groupIdUsage)
+
+	flags.StringVar(&zabbixHostname, "hostname", hostnameDefault, hostnameUsage)
+	flags.StringVar(&zabbixHostname, "H", hostnameDefault, hostnameUsage)
+
+	flags.StringVar(&zabbixMetricName, "metric", metricDefault, metricUsage)
+	flags.StringVar(&zabbixMetricName, "m", metricDefault, metricUsage)
+
+	flags.StringVar(&zabbixDBName, "dbname", dbNameDefault, dbNameUsage)
+	flags.StringVar(&zabbixDBName, "d", dbNameDefault, dbNameUsage)
+
+	flags.StringVar(&zabbixPartition, "partition", partitionDefault, partitionUsage)
+	flags.StringVar(&zabbixPartition, "p", partitionDefault, partitionUsage)
+
+	flags.StringVar(&zabbixDiscover, "discover", discoverDefault, discoverUsage)
+	flags.StringVar(&zabbixDiscover, "D", discoverDefault, discoverUsage)
+
+	flags.IntVar(&zabbixMaxAge, "maxage", maxAgeDefault, maxAgeUsage)
+	flags.IntVar(&zabbixMaxAge, "a", maxAgeDefault, maxAgeUsage)
+
+	flags.StringVar(&zabbixServer, "server", serverDefault, serverUsage)
+	flags.StringVar(&zabbixServer, "s", serverDefault, serverUsage)
+
+	flags.IntVar(&zabbixTimeout, "timeout", timeoutDefault, timeoutUsage)
+	flags.IntVar(&zabbixTimeout, "t", timeoutDefault, timeoutUsage)
+
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stdout, "Usage: check_mongodb_mms zabbix -g groupid -D hosts|databases|partitions [-H hostname] [-s server] [-t timeout]\n")
+		fmt.Fprintf(os.Stdout, "       check_mongodb_mms zabbix -g groupid -H hostname -m metric [-d dbname] [-p partition] [-a age] [-s server] [-t timeout]\n")
+		fmt.Fprintf(os.Stdout, "     -g, --groupid  %v\n", groupIdUsage)
+		fmt.Fprintf(os.Stdout, "     -H, --hostname %v\n", hostnameUsage)
+		fmt.Fprintf(os.Stdout, "     -m, --metric %v\n", metricUsage)
+		fmt.Fprintf(os.Stdout, "     -d, --dbname (default %v) %v\n", dbNameDefault, dbNameUsage)
+		fmt.Fprintf(os.Stdout, "     -p, --partition (default %v) %v\n", partitionDefault, partitionUsage)
+		fmt.Fprintf(os.Stdout, "     -D, --discover %v\n", discoverUsage)
+		fmt.Fprintf(os.Stdout, "     -a, --maxage (default %v) %v\n", maxAgeDefault, maxAgeUsage)
+		fmt.Fprintf(os.Stdout, "     -s, --server (default: %v) %v\n", serverDefault, serverUsage)
+		fmt.Fprintf(os.Stdout, "     -t, --timeout (default: %v) %v\n", timeoutDefault, timeoutUsage)
+	}
+	flags.Parse(args)
+
+	return flags
+}